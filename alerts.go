@@ -0,0 +1,174 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	ui "github.com/gizak/termui/v3"
+	customWidgets "replika.com/log-reader/widgets"
+)
+
+type AlertAction string
+
+const (
+	AlertFlash   AlertAction = "flash"
+	AlertBell    AlertAction = "bell"
+	AlertCommand AlertAction = "command"
+)
+
+type AlertConfig struct {
+	Title     string        `mapstructure:"title"`
+	Pattern   string        `mapstructure:"pattern"`
+	Level     string        `mapstructure:"level"`
+	Threshold int           `mapstructure:"threshold"`
+	Window    time.Duration `mapstructure:"window"`
+	Action    AlertAction   `mapstructure:"action"`
+	Command   string        `mapstructure:"command"`
+}
+
+type Alert struct {
+	Config AlertConfig
+
+	re    *regexp.Regexp
+	level Level
+	hits  []time.Time
+	mu    sync.Mutex
+}
+
+var alertInfoStyle = ui.NewStyle(ui.ColorRed)
+
+// newAlerts compiles the alerts of the config. Invalid patterns are reported by validateConfig,
+// which runs before on startup and on reload, so they're skipped here.
+func newAlerts(configs []AlertConfig) []*Alert {
+	alerts := []*Alert{}
+	for _, config := range configs {
+		alert := &Alert{Config: config}
+		if config.Pattern != "" {
+			re, err := regexp.Compile(config.Pattern)
+			if err != nil {
+				continue
+			}
+			alert.re = re
+		}
+		alert.level = parseLevel(config.Level)
+		if alert.re == nil && alert.level == LevelUnknown {
+			continue
+		}
+		if alert.Config.Action == "" {
+			alert.Config.Action = AlertFlash
+		}
+		alerts = append(alerts, alert)
+	}
+	return alerts
+}
+
+func (self *Alert) matches(line string) bool {
+	if self.re != nil && !self.re.MatchString(customWidgets.StripAsciiCodes(line)) {
+		return false
+	}
+	if self.level != LevelUnknown && detectLevel(line) < self.level {
+		return false
+	}
+	return true
+}
+
+// hit records a match and reports whether the alert should fire.
+// Without a threshold every match fires; otherwise the alert fires once
+// there are more than Threshold matches within Window, and starts counting anew.
+func (self *Alert) hit(now time.Time) bool {
+	if self.Config.Threshold <= 0 {
+		return true
+	}
+
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	window := self.Config.Window
+	if window <= 0 {
+		window = time.Minute
+	}
+	hits := self.hits[:0]
+	for _, t := range self.hits {
+		if now.Sub(t) < window {
+			hits = append(hits, t)
+		}
+	}
+	self.hits = append(hits, now)
+
+	if len(self.hits) > self.Config.Threshold {
+		self.hits = self.hits[:0]
+		return true
+	}
+	return false
+}
+
+func (self *Alert) title() string {
+	if self.Config.Title != "" {
+		return self.Config.Title
+	}
+	if self.Config.Pattern != "" {
+		return self.Config.Pattern
+	}
+	return strings.ToLower(self.Config.Level)
+}
+
+// checkAlerts is called for every ingested line, entry is the log entry the line belongs to.
+// Commands get the whole entry, they're run by runPendingAlerts once it's finished.
+func checkAlerts(ctx *Context, index int, line string, entry string) {
+	for _, alert := range ctx.Alerts {
+		if !alert.matches(line) || !alert.hit(time.Now()) {
+			continue
+		}
+
+		logTitle := ctx.Config.Logs[index].Title
+
+		switch alert.Config.Action {
+		case AlertBell:
+			fmt.Fprint(os.Stdout, "\a")
+
+		case AlertCommand:
+			state := ctx.LogStates[index]
+			if !slices.Contains(state.pendingAlerts, alert) {
+				state.pendingAlerts = append(state.pendingAlerts, alert)
+			}
+
+		default:
+			flashInfo(ctx, fmt.Sprintf("Alert [%s](fg:yellow) in [%s](fg:cyan): %s", alert.title(), logTitle, firstLine(entry)), alertInfoStyle)
+		}
+	}
+}
+
+// runPendingAlerts runs the commands of the alerts matched by lines of the newest entry of the tab,
+// called when the entry is finished.
+func runPendingAlerts(ctx *Context, index int) {
+	state := ctx.LogStates[index]
+	if len(state.pendingAlerts) == 0 || len(state.Entries) == 0 {
+		return
+	}
+	entry := state.Entries[0].Text
+	logTitle := ctx.Config.Logs[index].Title
+	for _, alert := range state.pendingAlerts {
+		cmd := exec.Command("sh", "-c", alert.Config.Command)
+		cmd.Stdin = strings.NewReader(customWidgets.StripAsciiCodes(entry) + "\n")
+		cmd.Env = append(os.Environ(), "ALERT_TITLE="+alert.title(), "LOG_TITLE="+logTitle)
+		go func() {
+			if err := cmd.Run(); err != nil {
+				flashInfo(ctx, fmt.Sprintf("Alert [%s](fg:yellow) command failed: %v", alert.title(), err), alertInfoStyle)
+			}
+		}()
+	}
+	state.pendingAlerts = nil
+}
+
+func firstLine(text string) string {
+	if idx := strings.IndexByte(text, '\n'); idx > -1 {
+		text = text[:idx]
+	}
+	return customWidgets.StripAsciiCodes(text)
+}
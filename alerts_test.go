@@ -0,0 +1,34 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAlertCommandGetsFinishedEntry(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "entry")
+	h := newHarness(t, LogConfig{Title: "api", EntryPattern: `^\d{4}-`})
+	h.ctx.Alerts = newAlerts([]AlertConfig{{Pattern: "ERROR", Action: AlertCommand, Command: "cat > " + out}})
+
+	h.feed(0, "2024-05-01 12:00:02 ERROR request failed", "  at handler.go:42")
+	time.Sleep(50 * time.Millisecond)
+	if _, err := os.Stat(out); err == nil {
+		t.Fatal("the command ran before the entry was finished")
+	}
+
+	h.feed(0, "  at server.go:7", "2024-05-01 12:00:03 INFO done")
+	want := "2024-05-01 12:00:02 ERROR request failed\n  at handler.go:42\n  at server.go:7\n"
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		got, _ := os.ReadFile(out)
+		if string(got) == want {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("the command got %q, want %q", got, want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,353 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	customWidgets "replika.com/log-reader/widgets"
+)
+
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999Z0700",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999Z0700",
+	"2006-01-02 15:04:05.999999999",
+	time.Stamp,
+	"15:04:05.999999999",
+}
+
+// parseTime parses a timestamp in one of the formats found in logs. Missing year or date are taken from now.
+func parseTime(text string, now time.Time) (time.Time, bool) {
+	text = strings.Replace(text, ",", ".", 1)
+	for _, layout := range timeLayouts {
+		t, err := time.ParseInLocation(layout, text, time.Local)
+		if err != nil {
+			continue
+		}
+		switch layout {
+		case time.Stamp:
+			t = t.AddDate(now.Year(), 0, 0)
+		case "15:04:05.999999999":
+			t = t.AddDate(now.Year(), int(now.Month())-1, now.Day()-1)
+		}
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+// entryTime is the time of the first timestamp in the first line of the entry, or now.
+func entryTime(text string, now time.Time) time.Time {
+	if match := timestampRe.FindString(firstLine(text)); match != "" {
+		if t, ok := parseTime(match, now); ok {
+			return t
+		}
+	}
+	return now
+}
+
+// parseTimeBound parses --since and --until, given as a timestamp or as a duration before now.
+func parseTimeBound(value string, now time.Time) (time.Time, error) {
+	if duration, err := time.ParseDuration(value); err == nil {
+		return now.Add(-duration), nil
+	}
+	if t, ok := parseTime(value, now); ok {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q, expected a duration like 15m or a timestamp", value)
+}
+
+var levelColors = map[Level]string{
+	LevelTrace: "\x1b[90m",
+	LevelDebug: "\x1b[90m",
+	LevelWarn:  "\x1b[33m",
+	LevelError: "\x1b[31m",
+	LevelFatal: "\x1b[1;31m",
+}
+
+// writeColored writes the entry colored by its level, continuation lines are dimmed.
+func writeColored(w io.Writer, text string) error {
+	first, rest, _ := strings.Cut(text, "\n")
+	color := levelColors[detectLevel(first)]
+	if color != "" {
+		first = color + first + "\x1b[0m"
+	}
+	if rest != "" {
+		first += "\n\x1b[2m" + rest + "\x1b[0m"
+	}
+	_, err := fmt.Fprintln(w, first)
+	return err
+}
+
+// catLog resolves the config for the cat command and returns the log to print.
+func catLog(opts *Options) (LogConfig, error) {
+	config := &Config{Logs: opts.Logs}
+	params := opts.Params
+
+	if len(opts.Logs) == 0 {
+		var problems []ConfigProblem
+		config, problems = readConfig()
+		if config != nil {
+			problems = append(problems, validateProfiles(config)...)
+		}
+		if len(problems) > 0 {
+			return LogConfig{}, fmt.Errorf("%s", problems[0])
+		}
+		if opts.Profile != "" {
+			profile := findProfile(config, opts.Profile)
+			if profile == nil {
+				return LogConfig{}, fmt.Errorf("unknown profile %q", opts.Profile)
+			}
+			applyProfile(config, profile, params)
+		}
+	}
+
+	titles := []string{}
+	index := -1
+	for i, logConfig := range config.Logs {
+		if len(opts.Args) > 0 && logConfig.Title == opts.Args[0] {
+			index = i
+		}
+		titles = append(titles, fmt.Sprintf("%q", logConfig.Title))
+	}
+	if len(opts.Args) == 0 && len(config.Logs) == 1 {
+		index = 0
+	}
+	if index == -1 && len(opts.Args) == 0 {
+		return LogConfig{}, fmt.Errorf("which log? one of %s", strings.Join(titles, ", "))
+	}
+	if index == -1 {
+		return LogConfig{}, fmt.Errorf("unknown log %q, expected one of %s", opts.Args[0], strings.Join(titles, ", "))
+	}
+	logConfig := config.Logs[index]
+
+	// there's nobody to ask, use remembered and default values
+	remembered := readRememberedParams()[paramsKey()]
+	for _, match := range placeholderRe.FindAllStringSubmatch(logConfig.Command, -1) {
+		name := match[1]
+		if _, ok := params[name]; ok {
+			continue
+		}
+		if value, ok := remembered[name]; ok {
+			params[name] = value
+		} else if i := slices.IndexFunc(config.Params, func(param ParamConfig) bool { return param.Name == name }); i > -1 && config.Params[i].Default != "" {
+			params[name] = config.Params[i].Default
+		} else {
+			return LogConfig{}, fmt.Errorf("param %q has no value, pass it with --%s <value>", name, name)
+		}
+	}
+
+	resolved := &Config{Logs: []LogConfig{logConfig}, Params: config.Params}
+	if problems := validateConfig(resolved, params); len(problems) > 0 {
+		return LogConfig{}, fmt.Errorf("%s", problems[0])
+	}
+	applyParams(resolved, params)
+	return resolved.Logs[0], nil
+}
+
+// runCat prints whole entries of a log to stdout without the UI, returns the exit code.
+// Errors and notices go to stderr.
+func runCat(opts *Options, stdout io.Writer, stderr io.Writer) int {
+	fail := func(err error) int {
+		fmt.Fprintf(stderr, "go-log-reader: %v\n", err)
+		return 1
+	}
+
+	logConfig, err := catLog(opts)
+	if err != nil {
+		return fail(err)
+	}
+
+	now := time.Now()
+	var grepRe, excludeRe *regexp.Regexp
+	var since, until time.Time
+	level := LevelUnknown
+	if opts.Grep != "" {
+		if grepRe, err = regexp.Compile(opts.Grep); err != nil {
+			return fail(fmt.Errorf("invalid --grep: %v", err))
+		}
+	}
+	if opts.Exclude != "" {
+		if excludeRe, err = regexp.Compile(opts.Exclude); err != nil {
+			return fail(fmt.Errorf("invalid --exclude: %v", err))
+		}
+	}
+	if opts.Level != "" {
+		if level = parseLevel(opts.Level); level == LevelUnknown {
+			return fail(fmt.Errorf("unknown level %q", opts.Level))
+		}
+	}
+	if opts.Since != "" {
+		if since, err = parseTimeBound(opts.Since, now); err != nil {
+			return fail(err)
+		}
+	}
+	if opts.Until != "" {
+		if until, err = parseTimeBound(opts.Until, now); err != nil {
+			return fail(err)
+		}
+	}
+
+	// files are read once instead of followed
+	var input io.Reader
+	var cmd *exec.Cmd
+	if logConfig.File != "" {
+		file, err := os.Open(expandHome(logConfig.File))
+		if err != nil {
+			return fail(err)
+		}
+		defer file.Close()
+		input = file
+	} else {
+		if input, cmd, err = openSource(logConfig, stderr); err != nil {
+			return fail(err)
+		}
+	}
+
+	rules := newEntryRules(logConfig)
+	filterRe := compileFilter(logConfig.Filter)
+	grouper := &entryGrouper{rules: rules}
+	output := bufio.NewWriter(stdout)
+	defer output.Flush()
+
+	write := func(text string) error {
+		entry := newLogEntry(text, entryTime(text, time.Now()))
+		stripped := customWidgets.StripAsciiCodes(text)
+		switch {
+		case filterRe != nil && !filterRe.MatchString(stripped),
+			grepRe != nil && !grepRe.MatchString(stripped),
+			excludeRe != nil && excludeRe.MatchString(stripped),
+			level != LevelUnknown && detectLevel(stripped) < level,
+			!since.IsZero() && entry.FirstSeen.Before(since),
+			!until.IsZero() && entry.FirstSeen.After(until):
+			return nil
+		}
+
+		var err error
+		switch opts.Format {
+		case "jsonl":
+			err = exportEntries(output, ExportJSONL, rules, []*LogEntry{entry})
+		case "color":
+			err = writeColored(output, stripped)
+		default:
+			err = exportEntries(output, ExportRaw, rules, []*LogEntry{entry})
+		}
+		if err == nil {
+			// entries are written as they come, for followed logs
+			err = output.Flush()
+		}
+		return err
+	}
+
+	// lines are read in the background, so an entry is written as soon as it times out
+	lines := make(chan string)
+	scanner := bufio.NewScanner(input)
+	go func() {
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+
+	// with an auto entry pattern, the first lines are kept until it's detected
+	var sample []sampledLine
+	var sampling <-chan time.Time
+	if logConfig.EntryPattern == autoEntryPattern {
+		sampling = time.After(sampleTime)
+	}
+	add := func(line string, now time.Time) error {
+		if sampling != nil {
+			sample = append(sample, sampledLine{text: line, time: now})
+			return nil
+		}
+		if text, ok := grouper.add(line, now); ok {
+			return write(text)
+		}
+		return nil
+	}
+	detect := func() error {
+		lines := []string{}
+		for _, line := range sample {
+			lines = append(lines, line.text)
+		}
+		format := detectHeader(lines)
+		fmt.Fprintf(stderr, "go-log-reader: %s\n", detectedText(logConfig.Title, format))
+		if format != nil {
+			logConfig.EntryPattern = format.Pattern
+		} else {
+			logConfig.EntryPattern = ""
+		}
+		rules = newEntryRules(logConfig)
+		grouper.rules = rules
+
+		sampling = nil
+		for _, line := range sample {
+			if err := add(line.text, line.time); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var idle <-chan time.Time
+	for done := false; !done; {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				done = true
+				break
+			}
+			if err := add(line, time.Now()); err != nil {
+				return fail(err)
+			}
+			if sampling != nil && len(sample) >= sampleLines {
+				if err := detect(); err != nil {
+					return fail(err)
+				}
+			}
+			if rules.timeout > 0 {
+				idle = time.After(rules.timeout)
+			}
+
+		case <-sampling:
+			if err := detect(); err != nil {
+				return fail(err)
+			}
+
+		case <-idle:
+			idle = nil
+			if text, ok := grouper.flush(); ok {
+				if err := write(text); err != nil {
+					return fail(err)
+				}
+			}
+		}
+	}
+	if sampling != nil {
+		if err := detect(); err != nil {
+			return fail(err)
+		}
+	}
+	if text, ok := grouper.flush(); ok {
+		if err := write(text); err != nil {
+			return fail(err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fail(err)
+	}
+	if cmd != nil {
+		if err := cmd.Wait(); err != nil {
+			return fail(err)
+		}
+	}
+	return 0
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// catLines runs cat on a file with the lines, returns what it wrote to stdout and stderr.
+func catLines(t *testing.T, opts *Options, lines ...string) (string, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	opts.Logs = []LogConfig{{Title: "app", File: path, EntryPattern: opts.Pattern}}
+	return runCatOutput(t, opts)
+}
+
+func runCatOutput(t *testing.T, opts *Options) (string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	if code := runCat(opts, &stdout, &stderr); code != 0 {
+		t.Fatalf("cat exited with %d: %s", code, stderr.String())
+	}
+	return stdout.String(), stderr.String()
+}
+
+func TestCatLevel(t *testing.T) {
+	stdout, _ := catLines(t, &Options{Pattern: `^\d{4}-`, Level: "warn"}, harnessLines...)
+	want := "2024-05-01 12:00:01 WARN slow request\n  took 2300ms\n" +
+		"2024-05-01 12:00:02 ERROR request failed\n  at handler.go:42\n  at server.go:7\n"
+	if stdout != want {
+		t.Errorf("cat --level warn printed\n%s\nwant\n%s", stdout, want)
+	}
+}
+
+func TestCatFormats(t *testing.T) {
+	stdout, _ := catLines(t, &Options{Pattern: `^\d{4}-`, Format: "jsonl"}, harnessLines...)
+	lines := strings.Split(strings.TrimSuffix(stdout, "\n"), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("cat --format jsonl printed %d lines, want 4:\n%s", len(lines), stdout)
+	}
+	var entry map[string]string
+	if err := json.Unmarshal([]byte(lines[1]), &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry["level"] != "WARN" || entry["message"] != "2024-05-01 12:00:01 WARN slow request\n  took 2300ms" {
+		t.Errorf("cat --format jsonl printed %v", entry)
+	}
+
+	stdout, _ = catLines(t, &Options{Pattern: `^\d{4}-`, Format: "color"}, harnessLines[3:5]...)
+	want := "\x1b[31m2024-05-01 12:00:02 ERROR request failed\x1b[0m\n\x1b[2m  at handler.go:42\x1b[0m\n"
+	if stdout != want {
+		t.Errorf("cat --format color printed %q, want %q", stdout, want)
+	}
+}
+
+func TestCatStdin(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stdin")
+	if err := os.WriteFile(path, []byte("first\nsecond\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	// stdin is read once per run, the test starts it over
+	osStdin := os.Stdin
+	os.Stdin = file
+	stdin.once, stdin.source = sync.Once{}, nil
+	t.Cleanup(func() {
+		os.Stdin = osStdin
+		stdin.once, stdin.source = sync.Once{}, nil
+	})
+
+	opts, err := parseArgs([]string{"cat", "-"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stdout, _ := runCatOutput(t, opts); stdout != "first\nsecond\n" {
+		t.Errorf("cat - printed %q", stdout)
+	}
+}
+
+func TestCatDetectedPattern(t *testing.T) {
+	stdout, stderr := catLines(t, &Options{Pattern: autoEntryPattern}, harnessLines...)
+	if !strings.HasPrefix(stderr, "go-log-reader: app: detected ") || !strings.Contains(stderr, "entry_pattern: '") {
+		t.Errorf("cat printed the notice %q", stderr)
+	}
+	if got := strings.Count(stdout, "2024-05-01"); got != 4 || !strings.Contains(stdout, "failed\n  at handler.go:42\n") {
+		t.Errorf("cat didn't group by the detected pattern:\n%s", stdout)
+	}
+}
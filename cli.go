@@ -0,0 +1,197 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// set by goreleaser
+var version = "dev"
+var commit = ""
+
+const usage = `Usage:
+  go-log-reader [options] [--<param> <value>...]
+  go-log-reader check [options]
+  go-log-reader cat [options] [<title>]
+  go-log-reader record <file> [options]
+  go-log-reader replay <file> [--speed <speed>]
+
+Sources (instead of the logs of the config file, can be repeated):
+  -l [<title>=]<command>  read the output of the command
+  -f <file>               follow the file
+  -                       read the standard input
+
+Options:
+  -c, --config <file>     config file (default: .go-log-reader.yaml in the current or home directory)
+  --pattern <regexp>      entry pattern for the logs given with -l, -f and -, or auto to detect it
+  --format <format>       output format: raw, jsonl or color
+  --profile <name>        profile to use
+  --<param> <value>       value of a param used in commands as ${param}
+  -h, --help              show this help
+  -v, --version           show the version
+
+Commands:
+  check                   validate the config file and exit
+  cat [<title>]           print entries of a log to stdout, without the UI
+  record <file>           run the reader, recording all lines read by the tabs to the file (gzipped if *.gz)
+  replay <file>           replay a recording with the recorded timing, P pauses, > changes the speed
+
+Options of cat:
+  --grep <regexp>         only print entries matching the regexp
+  --exclude <regexp>      skip entries matching the regexp
+  --level <level>         only print entries of the level or higher (trace, debug, info, warn, error, fatal)
+  --since <time>          skip entries before the time, given as a timestamp or a duration like 15m
+  --until <time>          skip entries after the time
+
+Options of replay:
+  --speed <speed>         replay speed: 1 (default), 10 or any other factor, or instant
+`
+
+var subcommands = []string{"check", "cat", "record", "replay"}
+
+type Options struct {
+	// Command is the subcommand, empty to run the reader
+	Command    string
+	ConfigFile string
+	Logs       []LogConfig
+	Params     map[string]string
+	Pattern    string
+	Format     string
+	Profile    string
+	Grep       string
+	Exclude    string
+	Level      string
+	Since      string
+	Until      string
+	Speed      float64
+	Help       bool
+	Version    bool
+	// Args are positional arguments of the subcommand
+	Args []string
+}
+
+// parseArgs parses the command line, flags may be given before or after the subcommand.
+func parseArgs(args []string) (*Options, error) {
+	opts := &Options{Params: map[string]string{}, Speed: 1}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		// value returns the value of the flag, given as the next argument or after "="
+		value := func() (string, error) {
+			if name, val, ok := strings.Cut(arg, "="); ok && strings.HasPrefix(name, "--") {
+				return val, nil
+			}
+			if i+1 >= len(args) {
+				return "", fmt.Errorf("flag %s needs a value", arg)
+			}
+			i++
+			return args[i], nil
+		}
+		name, _, _ := strings.Cut(arg, "=")
+
+		var err error
+		var val string
+		switch {
+		case arg == "-h" || arg == "--help":
+			opts.Help = true
+
+		case arg == "-v" || arg == "--version":
+			opts.Version = true
+
+		case arg == "-":
+			opts.Logs = append(opts.Logs, LogConfig{Title: "stdin", Stdin: true})
+
+		case arg == "-c" || name == "--config":
+			opts.ConfigFile, err = value()
+
+		case arg == "-l":
+			if val, err = value(); err == nil {
+				opts.Logs = append(opts.Logs, commandLog(val))
+			}
+
+		case arg == "-f":
+			if val, err = value(); err == nil {
+				opts.Logs = append(opts.Logs, LogConfig{Title: filepath.Base(val), File: val})
+			}
+
+		case name == "--pattern":
+			opts.Pattern, err = value()
+
+		case name == "--format":
+			opts.Format, err = value()
+
+		case name == "--profile":
+			opts.Profile, err = value()
+
+		case name == "--grep":
+			opts.Grep, err = value()
+
+		case name == "--exclude":
+			opts.Exclude, err = value()
+
+		case name == "--level":
+			opts.Level, err = value()
+
+		case name == "--since":
+			opts.Since, err = value()
+
+		case name == "--until":
+			opts.Until, err = value()
+
+		case name == "--speed":
+			if val, err = value(); err == nil {
+				opts.Speed, err = parseSpeed(val)
+			}
+
+		case strings.HasPrefix(arg, "--") && len(name) > 2:
+			if val, err = value(); err == nil {
+				opts.Params[name[2:]] = val
+			}
+
+		case strings.HasPrefix(arg, "-"):
+			err = fmt.Errorf("unknown flag %s", arg)
+
+		case opts.Command == "" && len(opts.Args) == 0 && slices.Contains(subcommands, arg):
+			opts.Command = arg
+
+		default:
+			opts.Args = append(opts.Args, arg)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.Command == "" && len(opts.Args) > 0 {
+		return nil, fmt.Errorf("unknown command %q", opts.Args[0])
+	}
+	switch opts.Format {
+	case "", "raw", "jsonl", "color":
+	default:
+		return nil, fmt.Errorf("unknown format %q, expected raw, jsonl or color", opts.Format)
+	}
+	if opts.Pattern != "" {
+		for i := range opts.Logs {
+			opts.Logs[i].EntryPattern = opts.Pattern
+		}
+	}
+	return opts, nil
+}
+
+// commandLog is the log given with -l, as "title=command" or just the command.
+func commandLog(arg string) LogConfig {
+	if title, command, ok := strings.Cut(arg, "="); ok && title != "" && !strings.Contains(title, " ") {
+		return LogConfig{Title: title, Command: command}
+	}
+	return LogConfig{Title: arg, Command: arg}
+}
+
+func versionString() string {
+	if commit != "" {
+		return fmt.Sprintf("go-log-reader %s (%s)", version, commit)
+	}
+	return "go-log-reader " + version
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseArgs(t *testing.T) {
+	tests := []struct {
+		args []string
+		want *Options
+		err  string
+	}{
+		{
+			args: []string{"cat", "-l", "api=tail -f api.log", "-f", "/var/log/web.log", "-l", "dmesg", "--pattern", "auto", "api"},
+			want: &Options{Command: "cat", Pattern: "auto", Args: []string{"api"}, Logs: []LogConfig{
+				{Title: "api", Command: "tail -f api.log", EntryPattern: "auto"},
+				{Title: "web.log", File: "/var/log/web.log", EntryPattern: "auto"},
+				{Title: "dmesg", Command: "dmesg", EntryPattern: "auto"},
+			}},
+		},
+		{
+			args: []string{"--env=prod", "-c", "logs.yaml", "--service", "api", "replay", "x.rec", "--speed", "10x"},
+			want: &Options{Command: "replay", ConfigFile: "logs.yaml", Speed: 10, Args: []string{"x.rec"},
+				Params: map[string]string{"env": "prod", "service": "api"}},
+		},
+		{args: []string{"-l"}, err: "flag -l needs a value"},
+		{args: []string{"cat", "--level"}, err: "flag --level needs a value"},
+		{args: []string{"-x"}, err: "unknown flag -x"},
+		{args: []string{"tail"}, err: `unknown command "tail"`},
+		{args: []string{"--speed", "fast"}, err: `invalid speed "fast", expected a number like 10 or instant`},
+		{args: []string{"--speed=-2"}, err: `invalid speed "-2", expected a number like 10 or instant`},
+		{args: []string{"cat", "--format", "xml"}, err: `unknown format "xml", expected raw, jsonl or color`},
+	}
+	for _, test := range tests {
+		opts, err := parseArgs(test.args)
+		if test.err != "" {
+			if err == nil || err.Error() != test.err {
+				t.Errorf("parseArgs(%q) returned error %v, want %q", test.args, err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseArgs(%q) failed: %v", test.args, err)
+			continue
+		}
+		if test.want.Params == nil {
+			test.want.Params = map[string]string{}
+		}
+		if test.want.Speed == 0 {
+			test.want.Speed = 1
+		}
+		if !reflect.DeepEqual(opts, test.want) {
+			t.Errorf("parseArgs(%q) = %+v, want %+v", test.args, opts, test.want)
+		}
+	}
+}
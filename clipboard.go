@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/atotto/clipboard"
+	ui "github.com/gizak/termui/v3"
+)
+
+type ClipboardMode string
+
+const (
+	ClipboardAuto    ClipboardMode = "auto"
+	ClipboardSystem  ClipboardMode = "system"
+	ClipboardOSC52   ClipboardMode = "osc52"
+	ClipboardFile    ClipboardMode = "file"
+	ClipboardCommand ClipboardMode = "command"
+)
+
+type ClipboardConfig struct {
+	Mode    ClipboardMode `mapstructure:"mode"`
+	File    string        `mapstructure:"file"`
+	Command string        `mapstructure:"command"`
+}
+
+// writeOSC52 asks the terminal to put the text to the clipboard. It works over ssh,
+// as long as the terminal (and tmux, with set-clipboard on) supports it.
+func writeOSC52(text string) error {
+	tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer tty.Close()
+
+	sequence := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\a"
+	if os.Getenv("TMUX") != "" {
+		sequence = "\x1bPtmux;\x1b" + sequence + "\x1b\\"
+	}
+
+	renderMu.Lock()
+	defer renderMu.Unlock()
+	_, err = tty.WriteString(sequence)
+	return err
+}
+
+// writeClipboard copies the text the configured way, returns the way it was copied.
+func writeClipboard(config ClipboardConfig, text string) (ClipboardMode, error) {
+	switch config.Mode {
+	case ClipboardSystem:
+		return ClipboardSystem, clipboard.WriteAll(text)
+
+	case ClipboardOSC52:
+		return ClipboardOSC52, writeOSC52(text)
+
+	case ClipboardFile:
+		return ClipboardFile, os.WriteFile(config.File, []byte(text), 0644)
+
+	case ClipboardCommand:
+		cmd := exec.Command("sh", "-c", config.Command)
+		cmd.Stdin = strings.NewReader(text)
+		return ClipboardCommand, cmd.Run()
+
+	default:
+		if !clipboard.Unsupported {
+			if err := clipboard.WriteAll(text); err == nil {
+				return ClipboardSystem, nil
+			}
+		}
+		return ClipboardOSC52, writeOSC52(text)
+	}
+}
+
+func copyToClipboard(ctx *Context, text string) {
+	mode, err := writeClipboard(ctx.Config.Clipboard, text)
+	if err != nil {
+		flashInfo(ctx, fmt.Sprintf("Copy failed (%s): %v", mode, err), alertInfoStyle)
+		return
+	}
+	flashInfo(ctx, fmt.Sprintf("Copied %d bytes (%s)", len(text), mode), ui.Theme.Block.Border)
+}
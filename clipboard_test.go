@@ -0,0 +1,41 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCopyToClipboard(t *testing.T) {
+	h := newHarness(t, LogConfig{Title: "api", EntryPattern: `^\d{4}-`})
+	file := filepath.Join(t.TempDir(), "clipboard.txt")
+	h.ctx.Config.Clipboard = ClipboardConfig{Mode: ClipboardFile, File: file}
+	h.feed(0, "2024-05-01 12:00:00 \x1b[32mINFO\x1b[0m starting server")
+	h.feed(0, harnessLines[1:]...)
+	copied := func() string {
+		t.Helper()
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	// the selected entry, without escape codes
+	h.press("<Down>", "<Down>", "<C-c>")
+	if got, want := copied(), "2024-05-01 12:00:02 ERROR request failed\n  at handler.go:42\n  at server.go:7"; got != want {
+		t.Errorf("copied %q, want %q", got, want)
+	}
+
+	// marked entries, the oldest first
+	h.press("<Space>", "<Down>", "<Down>", "<Space>", "<Up>", "<C-c>")
+	if got, want := copied(), "2024-05-01 12:00:00 INFO starting server\n2024-05-01 12:00:02 ERROR request failed\n  at handler.go:42\n  at server.go:7"; got != want {
+		t.Errorf("copied %q, want %q", got, want)
+	}
+
+	// the selected line of the log view
+	h.press("u", "<Tab>", "<Down>", "<C-c>")
+	if got, want := copied(), "  took 2300ms"; got != want {
+		t.Errorf("copied %q, want %q", got, want)
+	}
+}
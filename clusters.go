@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const clusterSimilarity = 0.5
+
+// Cluster is a log template: entries whose first lines only differ in variable tokens.
+type Cluster struct {
+	Tokens   []string
+	Count    int
+	LastSeen time.Time
+}
+
+// Clusterer groups entries into templates incrementally, roughly the way Drain does:
+// candidates share the token count and the first token, and the most similar one
+// above clusterSimilarity absorbs the entry, turning differing tokens into wildcards.
+type Clusterer struct {
+	groups   map[string][]*Cluster
+	clusters []*Cluster
+	mu       sync.Mutex
+}
+
+func NewClusterer() *Clusterer {
+	return &Clusterer{groups: map[string][]*Cluster{}}
+}
+
+func (self *Cluster) Template() string {
+	return strings.Join(self.Tokens, " ")
+}
+
+func (self *Clusterer) Add(line string, now time.Time) *Cluster {
+	tokens := templateTokens(line)
+	key := fmt.Sprintf("%d", len(tokens))
+	if len(tokens) > 0 {
+		key += " " + tokens[0]
+	}
+
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	var best *Cluster
+	bestSimilarity := 0.0
+	for _, cluster := range self.groups[key] {
+		similarity := tokenSimilarity(cluster.Tokens, tokens)
+		if similarity >= clusterSimilarity && similarity > bestSimilarity {
+			best, bestSimilarity = cluster, similarity
+		}
+	}
+
+	if best == nil {
+		best = &Cluster{Tokens: tokens}
+		self.groups[key] = append(self.groups[key], best)
+		self.clusters = append(self.clusters, best)
+	} else {
+		for i, token := range tokens {
+			if best.Tokens[i] != token {
+				best.Tokens[i] = wildcardToken
+			}
+		}
+	}
+	best.Count++
+	best.LastSeen = now
+	return best
+}
+
+// Sorted returns clusters, most frequent first.
+func (self *Clusterer) Sorted() []*Cluster {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	clusters := append([]*Cluster{}, self.clusters...)
+	sort.SliceStable(clusters, func(i, j int) bool {
+		return clusters[i].Count > clusters[j].Count
+	})
+	return clusters
+}
+
+func tokenSimilarity(template []string, tokens []string) float64 {
+	if len(tokens) == 0 {
+		return 1
+	}
+	same := 0
+	for i, token := range tokens {
+		if template[i] == token {
+			same++
+		}
+	}
+	return float64(same) / float64(len(tokens))
+}
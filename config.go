@@ -0,0 +1,431 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+type ConfigProblem struct {
+	File    string
+	Line    int
+	Message string
+}
+
+func (self ConfigProblem) String() string {
+	if self.File == "" {
+		return self.Message
+	}
+	if self.Line == 0 {
+		return fmt.Sprintf("%s: %s", self.File, self.Message)
+	}
+	return fmt.Sprintf("%s:%d: %s", self.File, self.Line, self.Message)
+}
+
+var placeholderRe = regexp.MustCompile(`\$\{(\w+)\}`)
+var errorLineRe = regexp.MustCompile(`line (\d+)`)
+var invalidKeysRe = regexp.MustCompile(`'([^']*)' has invalid keys: (.*)`)
+var decodedPathRe = regexp.MustCompile(`([^.\[\]]+)|\[(\d+)\]`)
+
+// readConfig reads and decodes the config file found by viper. If there is no config file
+// (and none was given explicitly), the default config is returned.
+func readConfig() (*Config, []ConfigProblem) {
+	if err := viper.ReadInConfig(); err != nil {
+		var notFound viper.ConfigFileNotFoundError
+		if errors.As(err, &notFound) {
+			return &defaultLogConfig, nil
+		}
+		problem := ConfigProblem{File: viper.ConfigFileUsed(), Message: err.Error()}
+		if match := errorLineRe.FindStringSubmatch(err.Error()); match != nil {
+			problem.Line, _ = strconv.Atoi(match[1])
+		}
+		return nil, []ConfigProblem{problem}
+	}
+
+	file := viper.ConfigFileUsed()
+	lines := readConfigLines(file)
+	problems := []ConfigProblem{}
+
+	config := &Config{}
+	if err := viper.Unmarshal(config); err != nil {
+		return nil, []ConfigProblem{{File: file, Message: err.Error()}}
+	}
+	for i := range config.Profiles {
+		config.Profiles[i].Params = lines.keyCase(config.Profiles[i].Params, "profiles", i, "params")
+	}
+
+	// decode again, only to find unknown keys
+	err := viper.Unmarshal(&Config{}, func(decoderConfig *mapstructure.DecoderConfig) {
+		decoderConfig.ErrorUnused = true
+	})
+	var decodeErr *mapstructure.Error
+	if errors.As(err, &decodeErr) {
+		for _, message := range decodeErr.Errors {
+			match := invalidKeysRe.FindStringSubmatch(message)
+			if match == nil {
+				problems = append(problems, ConfigProblem{File: file, Message: message})
+				continue
+			}
+			for _, key := range strings.Split(match[2], ", ") {
+				path := key
+				if match[1] != "" {
+					path = match[1] + "." + key
+				}
+				problems = append(problems, ConfigProblem{
+					File:    file,
+					Line:    lines.line(decodedPath(path)...),
+					Message: fmt.Sprintf("unknown key %q", path),
+				})
+			}
+		}
+	}
+
+	return config, problems
+}
+
+// validateConfig checks the decoded config. Params are the values given on the command line.
+func validateConfig(config *Config, params map[string]string) []ConfigProblem {
+	file := viper.ConfigFileUsed()
+	lines := readConfigLines(file)
+	problems := []ConfigProblem{}
+	problem := func(line int, format string, args ...interface{}) {
+		problems = append(problems, ConfigProblem{File: file, Line: line, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if len(config.Logs) == 0 {
+		problem(0, "no logs configured")
+	}
+
+	declared := map[string]bool{}
+	// params set by a profile don't need to be declared
+	for _, profile := range config.Profiles {
+		for key := range profile.Params {
+			declared[key] = true
+		}
+	}
+	for i, param := range config.Params {
+		if param.Name == "" {
+			problem(lines.line("params", i), "params[%d]: missing name", i)
+			continue
+		}
+		if slices.ContainsFunc(config.Params[:i], func(other ParamConfig) bool { return other.Name == param.Name }) {
+			problem(lines.line("params", i, "name"), "params[%d]: duplicate param %q", i, param.Name)
+		}
+		declared[param.Name] = true
+
+		if param.Default != "" && len(param.Choices) > 0 && !slices.Contains(param.Choices, param.Default) {
+			problem(lines.line("params", i, "default"), "params[%d] (%s): default %q is not one of the choices", i, param.Name, param.Default)
+		}
+	}
+
+	for i, logConfig := range config.Logs {
+		name := fmt.Sprintf("logs[%d]", i)
+		if logConfig.Title != "" {
+			name += fmt.Sprintf(" (%s)", logConfig.Title)
+		}
+		log := logKey(logConfig, i)
+		line := lines.line("logs", log)
+
+		if logConfig.Title == "" {
+			problem(line, "%s: missing title", name)
+		}
+		sources := 0
+		for _, set := range []bool{logConfig.Command != "", logConfig.File != "", logConfig.Stdin} {
+			if set {
+				sources++
+			}
+		}
+		if sources == 0 {
+			problem(line, "%s: missing command, file or stdin", name)
+		} else if sources > 1 {
+			problem(line, "%s: only one of command, file and stdin can be set", name)
+		}
+		if logConfig.Stdin && slices.ContainsFunc(config.Logs[:i], func(other LogConfig) bool { return other.Stdin }) {
+			problem(line, "%s: only one log can read stdin", name)
+		}
+		if _, err := regexp.Compile(logConfig.EntryPattern); err != nil {
+			problem(lines.line("logs", log, "entry_pattern"), "%s: invalid entry_pattern: %v", name, err)
+		}
+		for j, pattern := range logConfig.EntryPatterns {
+			if _, err := regexp.Compile(pattern); err != nil {
+				problem(lines.line("logs", log, "entry_patterns", j), "%s: invalid entry_patterns[%d]: %v", name, j, err)
+			}
+		}
+		if _, err := regexp.Compile(logConfig.EntryEndPattern); err != nil {
+			problem(lines.line("logs", log, "entry_end_pattern"), "%s: invalid entry_end_pattern: %v", name, err)
+		}
+		if logConfig.MaxEntryLines < 0 {
+			problem(lines.line("logs", log, "max_entry_lines"), "%s: max_entry_lines can't be negative", name)
+		}
+		if timeout, err := time.ParseDuration(logConfig.EntryTimeout); logConfig.EntryTimeout != "" && (err != nil || timeout < 0) {
+			problem(lines.line("logs", log, "entry_timeout"), "%s: invalid entry_timeout %q, expected a duration like 2s", name, logConfig.EntryTimeout)
+		}
+		if _, err := regexp.Compile(logConfig.Filter); err != nil {
+			problem(lines.line("logs", log, "filter"), "%s: invalid filter: %v", name, err)
+		}
+		for _, match := range placeholderRe.FindAllStringSubmatch(logConfig.Command, -1) {
+			if _, ok := params[match[1]]; !ok && !declared[match[1]] {
+				problem(lines.line("logs", log, "command"), "%s: unresolved placeholder %s, declare it in params or pass it with --%s <value>", name, match[0], match[1])
+			}
+		}
+	}
+
+	for i, alert := range config.Alerts {
+		name := fmt.Sprintf("alerts[%d]", i)
+		if alert.Title != "" {
+			name += fmt.Sprintf(" (%s)", alert.Title)
+		}
+		if alert.Pattern == "" && alert.Level == "" {
+			problem(lines.line("alerts", i), "%s: either pattern or level is required", name)
+		}
+		if _, err := regexp.Compile(alert.Pattern); err != nil {
+			problem(lines.line("alerts", i, "pattern"), "%s: invalid pattern: %v", name, err)
+		}
+		if alert.Level != "" && parseLevel(alert.Level) == LevelUnknown {
+			problem(lines.line("alerts", i, "level"), "%s: unknown level %q", name, alert.Level)
+		}
+		switch alert.Action {
+		case "", AlertFlash, AlertBell:
+		case AlertCommand:
+			if alert.Command == "" {
+				problem(lines.line("alerts", i, "action"), "%s: missing command", name)
+			}
+		default:
+			problem(lines.line("alerts", i, "action"), "%s: unknown action %q", name, alert.Action)
+		}
+	}
+
+	pipeKeys := map[string]bool{}
+	for i, pipe := range config.Pipes {
+		if pipe.Command == "" {
+			problem(lines.line("pipes", i), "pipes[%d]: missing command", i)
+		}
+		if slices.Contains(builtinKeys, pipe.Key) {
+			problem(lines.line("pipes", i, "key"), "pipes[%d]: key %q is used by the reader", i, pipe.Key)
+		} else if pipeKeys[pipe.Key] {
+			problem(lines.line("pipes", i, "key"), "pipes[%d]: duplicate key %q", i, pipe.Key)
+		}
+		if pipe.Key != "" {
+			pipeKeys[pipe.Key] = true
+		}
+	}
+
+	switch config.Clipboard.Mode {
+	case "", ClipboardAuto, ClipboardSystem, ClipboardOSC52:
+	case ClipboardFile:
+		if config.Clipboard.File == "" {
+			problem(lines.line("clipboard", "mode"), "clipboard: missing file")
+		}
+	case ClipboardCommand:
+		if config.Clipboard.Command == "" {
+			problem(lines.line("clipboard", "mode"), "clipboard: missing command")
+		}
+	default:
+		problem(lines.line("clipboard", "mode"), "clipboard: unknown mode %q", config.Clipboard.Mode)
+	}
+
+	return problems
+}
+
+// validateProfiles checks profiles, before any of them is applied.
+func validateProfiles(config *Config) []ConfigProblem {
+	file := viper.ConfigFileUsed()
+	lines := readConfigLines(file)
+	problems := []ConfigProblem{}
+	problem := func(line int, format string, args ...interface{}) {
+		problems = append(problems, ConfigProblem{File: file, Line: line, Message: fmt.Sprintf(format, args...)})
+	}
+
+	profiles := map[string]bool{}
+	for i, profile := range config.Profiles {
+		if profile.Name == "" {
+			problem(lines.line("profiles", i), "profiles[%d]: missing name", i)
+		} else if profiles[profile.Name] {
+			problem(lines.line("profiles", i, "name"), "profiles[%d]: duplicate profile %q", i, profile.Name)
+		}
+		profiles[profile.Name] = true
+
+		for _, key := range []string{"logs", "disable"} {
+			titles := profile.Logs
+			if key == "disable" {
+				titles = profile.Disable
+			}
+			for j, title := range titles {
+				if !slices.ContainsFunc(config.Logs, func(logConfig LogConfig) bool { return logConfig.Title == title }) {
+					problem(lines.line("profiles", i, key, j), "profiles[%d] (%s): unknown log %q", i, profile.Name, title)
+				}
+			}
+		}
+	}
+	return problems
+}
+
+// configLines finds the lines of keys in the config file, to report problems where they are.
+type configLines struct {
+	root *yaml.Node
+}
+
+// titled selects the item of a list by its title, like a log.
+type titled string
+
+// readConfigLines parses the file, JSON configs included, as they're valid yaml.
+func readConfigLines(file string) *configLines {
+	self := &configLines{}
+	if file == "" {
+		return self
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return self
+	}
+	var document yaml.Node
+	if yaml.Unmarshal(data, &document) == nil && len(document.Content) > 0 {
+		self.root = document.Content[0]
+	}
+	return self
+}
+
+// line returns the line of the key at the path of keys, list indexes and titled items.
+// If only the start of the path is found, the line of its last part is returned, or 0.
+func (self *configLines) line(path ...interface{}) int {
+	_, line := self.find(path...)
+	return line
+}
+
+// find returns the node at the path, or nil, and the line of the last part of the path found.
+func (self *configLines) find(path ...interface{}) (*yaml.Node, int) {
+	node := self.root
+	line := 0
+	for _, part := range path {
+		if node == nil {
+			break
+		}
+		var next *yaml.Node
+		switch part := part.(type) {
+		case string:
+			if node.Kind == yaml.MappingNode {
+				for i := 0; i+1 < len(node.Content); i += 2 {
+					// viper keys are case insensitive
+					if strings.EqualFold(node.Content[i].Value, part) {
+						line, next = node.Content[i].Line, node.Content[i+1]
+						break
+					}
+				}
+			}
+		case int:
+			if node.Kind == yaml.SequenceNode && part >= 0 && part < len(node.Content) {
+				next = node.Content[part]
+				line = next.Line
+			}
+		case titled:
+			if node.Kind == yaml.SequenceNode {
+				for _, item := range node.Content {
+					if (&configLines{root: item}).value("title") == string(part) {
+						next = item
+						line = next.Line
+						break
+					}
+				}
+			}
+		}
+		node = next
+	}
+	return node, line
+}
+
+// keyCase returns the values with the keys written as in the mapping at the path. Viper lowercases
+// the keys of maps, but params are case sensitive.
+func (self *configLines) keyCase(values map[string]string, path ...interface{}) map[string]string {
+	node, _ := self.find(path...)
+	if node == nil || node.Kind != yaml.MappingNode {
+		return values
+	}
+	result := map[string]string{}
+	for key, value := range values {
+		result[key] = value
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i].Value
+		if value, ok := values[strings.ToLower(key)]; ok {
+			delete(result, strings.ToLower(key))
+			result[key] = value
+		}
+	}
+	return result
+}
+
+// value returns the scalar value of the key of the root mapping.
+func (self *configLines) value(key string) string {
+	if self.root != nil && self.root.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(self.root.Content); i += 2 {
+			if strings.EqualFold(self.root.Content[i].Value, key) {
+				return self.root.Content[i+1].Value
+			}
+		}
+	}
+	return ""
+}
+
+// decodedPath splits a mapstructure path like logs[0].alerts into keys and list indexes.
+func decodedPath(path string) []interface{} {
+	parts := []interface{}{}
+	for _, match := range decodedPathRe.FindAllStringSubmatch(path, -1) {
+		if match[1] != "" {
+			parts = append(parts, match[1])
+		} else if index, err := strconv.Atoi(match[2]); err == nil {
+			parts = append(parts, index)
+		}
+	}
+	return parts
+}
+
+// logKey selects the log in the config file. Profiles drop logs, so they're found by title.
+func logKey(logConfig LogConfig, index int) interface{} {
+	if logConfig.Title != "" {
+		return titled(logConfig.Title)
+	}
+	return index
+}
+
+// runCheck validates the config and prints problems, returns the exit code.
+func runCheck(opts *Options) int {
+	params := opts.Params
+
+	config, problems := readConfig()
+	if config != nil {
+		problems = append(problems, validateProfiles(config)...)
+	}
+	if opts.Profile != "" && config != nil {
+		if profile := findProfile(config, opts.Profile); profile != nil {
+			applyProfile(config, profile, params)
+		} else {
+			problems = append(problems, ConfigProblem{File: viper.ConfigFileUsed(), Message: fmt.Sprintf("unknown profile %q", opts.Profile)})
+		}
+	}
+	if config != nil {
+		problems = append(problems, validateConfig(config, params)...)
+	}
+
+	for _, problem := range problems {
+		fmt.Fprintln(os.Stderr, problem)
+	}
+	if len(problems) > 0 {
+		return 1
+	}
+
+	file := viper.ConfigFileUsed()
+	if file == "" || config == &defaultLogConfig {
+		file = "default config"
+	}
+	fmt.Printf("%s: OK, %d logs\n", file, len(config.Logs))
+	return 0
+}
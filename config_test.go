@@ -0,0 +1,187 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+
+	ui "github.com/gizak/termui/v3"
+	"github.com/spf13/viper"
+)
+
+// useConfig makes the text the config file read by viper.
+func useConfig(t *testing.T, name string, text string) {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+		t.Fatal(err)
+	}
+	viper.Reset()
+	viper.SetConfigType("yaml")
+	viper.SetConfigFile(path)
+	t.Cleanup(viper.Reset)
+}
+
+func problemLines(t *testing.T) []int {
+	config, problems := readConfig()
+	if config != nil {
+		problems = append(problems, validateProfiles(config)...)
+		problems = append(problems, validateConfig(config, map[string]string{})...)
+	}
+	lines := []int{}
+	for _, problem := range problems {
+		t.Log(problem)
+		lines = append(lines, problem.Line)
+	}
+	return lines
+}
+
+func TestConfigProblemLines(t *testing.T) {
+	useConfig(t, "config.yaml", `logs:
+  - title: "^\\d{4}("
+    command: echo
+  - title: api
+    command: echo
+    entry_pattern: "^\\d{4}("
+    colour: red
+profiles:
+  - name: dev
+    disable: [api, web]
+`)
+	if got, want := problemLines(t), []int{7, 10, 6}; !slices.Equal(got, want) {
+		t.Errorf("problems on lines %v, want %v", got, want)
+	}
+}
+
+func TestConfigProblemLinesJSON(t *testing.T) {
+	useConfig(t, "config.json", `{
+  "logs": [
+    {"title": "api", "command": "echo"},
+    {
+      "title": "web",
+      "command": "echo",
+      "filter": "(unclosed"
+    }
+  ]
+}
+`)
+	if got, want := problemLines(t), []int{7}; !slices.Equal(got, want) {
+		t.Errorf("problems on lines %v, want %v", got, want)
+	}
+}
+
+func TestConfigPipeKeys(t *testing.T) {
+	useConfig(t, "config.yaml", `logs:
+  - title: api
+    command: echo
+pipes:
+  - key: j
+    command: jq .
+  - key: J
+    command: jq -c .
+  - command: wc -l
+  - key: j
+    command: base64 -d
+  - command: sort
+`)
+	if got, want := problemLines(t), []int{7, 10}; !slices.Equal(got, want) {
+		t.Errorf("problems on lines %v, want %v", got, want)
+	}
+}
+
+func TestProfileParamsKeepCase(t *testing.T) {
+	useConfig(t, "config.yaml", `logs:
+  - title: api
+    command: ssh ${apiHost} tail -f app.log
+profiles:
+  - name: prod
+    params:
+      apiHost: prod.example.com
+`)
+	config, problems := readConfig()
+	if len(problems) > 0 {
+		t.Fatal(problems)
+	}
+	params := map[string]string{}
+	applyProfile(config, findProfile(config, "prod"), params)
+	if problems := validateConfig(config, params); len(problems) > 0 {
+		t.Fatal(problems)
+	}
+	applyParams(config, params)
+	if got, want := config.Logs[0].Command, "ssh prod.example.com tail -f app.log"; got != want {
+		t.Errorf("command %q, want %q", got, want)
+	}
+}
+
+// drawNowhere lets forms shown before the reader starts run without a terminal.
+func drawNowhere(t *testing.T) {
+	drawItems = func(...ui.Drawable) {}
+	clearScreen = func() {}
+	terminalDimensions = func() (int, int) { return 80, 24 }
+	t.Cleanup(func() {
+		drawItems = ui.Render
+		clearScreen = ui.Clear
+		terminalDimensions = ui.TerminalDimensions
+	})
+}
+
+// keys returns the events of the keys, for forms reading them before the reader starts.
+func keys(ids ...string) <-chan ui.Event {
+	events := make(chan ui.Event, len(ids))
+	for _, id := range ids {
+		events <- ui.Event{Type: ui.KeyboardEvent, ID: id}
+	}
+	return events
+}
+
+func TestProfileRememberedApartFromParams(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	drawNowhere(t)
+	useConfig(t, "config.yaml", "")
+	config := &Config{
+		Logs:     []LogConfig{{Title: "api", Command: "tail ${profile}.log"}},
+		Profiles: []ProfileConfig{{Name: "dev"}, {Name: "prod"}},
+	}
+
+	profile, _, _ := selectProfile(keys("<Down>", "<Enter>"), config, "")
+	if profile.Name != "prod" {
+		t.Fatalf("picked profile %q", profile.Name)
+	}
+	// the ${profile} param isn't prefilled with the profile
+	values, _ := askParams(keys("x", "<Enter>"), config, map[string]string{})
+	if values["profile"] != "x" {
+		t.Errorf("profile param is %q, want %q", values["profile"], "x")
+	}
+	// and the picked profile isn't the param value
+	if profile, _, _ := selectProfile(keys("<Enter>"), config, ""); profile == nil || profile.Name != "prod" {
+		t.Errorf("the remembered profile is %v, want prod", profile)
+	}
+}
+
+func TestParamsPrefilledWithRememberedValues(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	drawNowhere(t)
+	useConfig(t, "config.yaml", "")
+	config := &Config{
+		Params: []ParamConfig{{Name: "host", Default: "staging"}, {Name: "service", Choices: []string{"api", "worker", "web"}}},
+		Logs:   []LogConfig{{Title: "api", Command: "ssh ${host} tail -f /var/log/${service}.log ${lines}"}},
+	}
+	if err := rememberParams(map[string]string{"host": "prod-1", "service": "worker", "lines": "100"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// lines is given on the command line, the others are confirmed with their remembered values
+	values, ok := askParams(keys("<Enter>", "<Enter>"), config, map[string]string{"lines": "20"})
+	want := map[string]string{"host": "prod-1", "service": "worker", "lines": "20"}
+	if !ok || !reflect.DeepEqual(values, want) {
+		t.Errorf("params %v, want %v", values, want)
+	}
+
+	// values changed in the form are remembered for the next time
+	values, _ = askParams(keys("2", "<Enter>", "<Down>", "<Enter>", "<Enter>"), config, map[string]string{})
+	want = map[string]string{"host": "prod-12", "service": "web", "lines": "100"}
+	if !reflect.DeepEqual(values, want) || !reflect.DeepEqual(readRememberedParams()[paramsKey()], want) {
+		t.Errorf("params %v, remembered %v, want %v", values, readRememberedParams()[paramsKey()], want)
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	ui "github.com/gizak/termui/v3"
+	customWidgets "replika.com/log-reader/widgets"
+)
+
+// autoEntryPattern as entry_pattern detects the pattern from the first lines of the log.
+const autoEntryPattern = "auto"
+
+// sampleLines is how many lines are looked at to detect the entry pattern, at most sampleTime after the first one.
+const sampleLines = 200
+const sampleTime = time.Second
+
+type headerFormat struct {
+	Name    string
+	Pattern string
+	re      *regexp.Regexp
+}
+
+// headerFormats are tried in order, more specific ones first, the one matching most lines wins.
+var headerFormats = []*headerFormat{
+	{Name: "Python logging", Pattern: `^\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2},\d{3} `},
+	{Name: "Python logging", Pattern: `^(DEBUG|INFO|WARNING|ERROR|CRITICAL):[\w.]+:`},
+	{Name: "Java logging", Pattern: `^\d{2}:\d{2}:\d{2}\.\d{3} \[`},
+	{Name: "Java logging", Pattern: `^\w{3} \d{1,2}, \d{4} \d{1,2}:\d{2}:\d{2} [AP]M `},
+	{Name: "ISO timestamp", Pattern: `^\[?\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}`},
+	{Name: "syslog", Pattern: `^\w{3} [ \d]\d \d{2}:\d{2}:\d{2} `},
+	{Name: "nginx access log", Pattern: `^\S+ \S+ \S+ \[\d{2}/\w{3}/\d{4}:\d{2}:\d{2}:\d{2}`},
+	{Name: "nginx error log", Pattern: `^\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2} \[\w+\]`},
+	{Name: "Go log", Pattern: `^\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2}`},
+	{Name: "klog", Pattern: `^[IWEF]\d{4} \d{2}:\d{2}:\d{2}`},
+	{Name: "JSON", Pattern: `^\{`},
+}
+
+func init() {
+	for _, format := range headerFormats {
+		format.re = regexp.MustCompile(format.Pattern)
+	}
+}
+
+// detectHeader returns the header format matching most of the lines, nil if none matches at least 5% of them.
+func detectHeader(lines []string) *headerFormat {
+	var best *headerFormat
+	bestCount := 0
+	for _, format := range headerFormats {
+		count := 0
+		for _, line := range lines {
+			if format.re.MatchString(customWidgets.StripAsciiCodes(line)) {
+				count++
+			}
+		}
+		if count > bestCount {
+			best, bestCount = format, count
+		}
+	}
+	if bestCount == 0 || bestCount*20 < len(lines) {
+		return nil
+	}
+	return best
+}
+
+// detectedText describes the detected header for the Info bar, so the pattern can be copied to the config.
+func detectedText(title string, format *headerFormat) string {
+	if format == nil {
+		return title + ": no common entry header found, every line is an entry"
+	}
+	return title + ": detected " + format.Name + " entries, entry_pattern: " + yamlQuote(format.Pattern)
+}
+
+// yamlQuote writes the pattern as a yaml single-quoted string, to be copied to the config.
+func yamlQuote(pattern string) string {
+	return "'" + strings.ReplaceAll(pattern, "'", "''") + "'"
+}
+
+// tabStatus is the status line of the tab in the Info bar. It keeps the detected entry pattern visible.
+func tabStatus(ctx *Context, index int) string {
+	state := ctx.LogStates[index]
+	switch {
+	case !state.detected:
+		return ""
+	case state.detectedPattern == "":
+		return "entry_pattern: auto, every line is an entry"
+	}
+	return "entry_pattern: " + yamlQuote(state.detectedPattern)
+}
+
+// sampleLine keeps the line while the entry pattern of the tab is being detected, returns false
+// if the pattern is known.
+func sampleLine(ctx *Context, index int, str string, now time.Time) bool {
+	state := ctx.LogStates[index]
+	if ctx.Config.Logs[index].EntryPattern != autoEntryPattern || state.detected {
+		// the pattern may have changed on reload while sampling
+		flushSample(ctx, index)
+		return false
+	}
+
+	if len(state.sample) == 0 {
+		// slow logs are detected from the lines read so far
+		generation := state.generation
+		time.AfterFunc(sampleTime, func() {
+			ctx.tabsMu.Lock()
+			defer ctx.tabsMu.Unlock()
+			// a restarted log is sampled again
+			if index := tabIndex(ctx, state); index != -1 && state.generation == generation && len(state.sample) > 0 {
+				flushSample(ctx, index)
+				if index == ctx.Tabs.ActiveTabIndex {
+					setViewText(ctx)
+					render(ctx.LogTables[index], rightPane(ctx))
+				}
+			}
+		})
+	}
+	state.sample = append(state.sample, sampledLine{text: str, time: now})
+	if len(state.sample) >= sampleLines {
+		flushSample(ctx, index)
+	}
+	return true
+}
+
+// flushSample detects the entry pattern from the sampled lines, if it's still to be detected,
+// and adds the lines to the tab.
+func flushSample(ctx *Context, index int) {
+	state := ctx.LogStates[index]
+	logConfig := ctx.Config.Logs[index]
+	if logConfig.EntryPattern == autoEntryPattern && !state.detected && len(state.sample) > 0 {
+		lines := []string{}
+		for _, line := range state.sample {
+			lines = append(lines, line.text)
+		}
+		format := detectHeader(lines)
+		state.detected = true
+		if format != nil {
+			state.detectedPattern = format.Pattern
+		}
+		flashInfoFor(ctx, detectedText(logConfig.Title, format), ui.Theme.Block.Border, time.Second * 15)
+		if index == ctx.Tabs.ActiveTabIndex {
+			updateInfoStatus(ctx)
+		}
+	}
+
+	sample := state.sample
+	state.sample = nil
+	for _, line := range sample {
+		groupLine(ctx, index, line.text, line.time)
+	}
+}
@@ -0,0 +1,33 @@
+package main
+
+import "testing"
+
+func TestDetectHeader(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		want  string
+	}{
+		{"ISO timestamp", []string{"2024-05-01T12:00:00.123Z INFO started", "  detail", "2024-05-01T12:00:01.000Z WARN slow"}, "ISO timestamp"},
+		{"syslog", []string{"May  1 12:00:00 host sshd[1]: accepted", "May 12 12:00:01 host cron[2]: job"}, "syslog"},
+		{"Java logging", []string{"12:00:00.123 [main] INFO App - started", "java.lang.IllegalStateException: x", "\tat App.main(App.java:3)"}, "Java logging"},
+		{"Python logging", []string{"2024-05-01 12:00:00,123 - app - INFO - started", "Traceback (most recent call last):", "  File \"app.py\", line 1"}, "Python logging"},
+		{"Python default format", []string{"WARNING:root:disk almost full", "ERROR:app.db:connection lost"}, "Python logging"},
+		{"nginx access log", []string{`127.0.0.1 - - [01/May/2024:12:00:00 +0000] "GET / HTTP/1.1" 200 612`}, "nginx access log"},
+		{"nginx error log", []string{"2024/05/01 12:00:00 [error] 7#7: *1 open() failed"}, "nginx error log"},
+		{"JSON", []string{`{"level":"info","msg":"started"}`, `{"level":"error","msg":"failed"}`}, "JSON"},
+		{"colored", []string{"\x1b[90m2024-05-01T12:00:00Z\x1b[0m INFO started"}, "ISO timestamp"},
+		{"no header", []string{"hello", "world"}, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := ""
+			if format := detectHeader(test.lines); format != nil {
+				got = format.Name
+			}
+			if got != test.want {
+				t.Errorf("detected %q, want %q", got, test.want)
+			}
+		})
+	}
+}
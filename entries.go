@@ -0,0 +1,311 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+)
+
+type LogOccurrence struct {
+	Time time.Time
+	Text string
+}
+
+type LogEntry struct {
+	Text      string
+	FirstSeen time.Time
+	LastSeen  time.Time
+	Count     int
+	Cluster   *Cluster
+	Marked    bool
+
+	// Occurrences holds every merged duplicate (including the first one), oldest first.
+	// It is nil for entries that were never merged.
+	Occurrences []LogOccurrence
+
+	// payloadRows are the rows of the log view with pretty payloads, made from the rows joined in payloadKey
+	payloadKey  string
+	payloadRows []string
+}
+
+type LogState struct {
+	// Entries are kept newest first
+	Entries []*LogEntry
+	// Visible are the entries passing Filter, parallel to the table rows
+	Visible  []*LogEntry
+	Filter   func(*LogEntry) bool
+	Clusters *Clusterer
+	Rates    *RateCounter
+
+	// filterRe is the filter of the log config, applied along with Filter
+	filterRe *regexp.Regexp
+	// configTitle is the title of the log in the config file, empty for tabs opened in the app
+	configTitle string
+
+	// Ended is set when the input of the log ended
+	Ended bool
+
+	// cmd is the running log process, nil once stopped or when reading stdin
+	cmd *exec.Cmd
+	// generation is increased whenever the log is restarted, so the previous listener stops
+	generation int
+	// source is the tab this one was duplicated from, which listener adds lines to both
+	source *LogState
+
+	entryRules *entryRules
+	// entryLines is the number of lines of the newest entry, entryClosed is set when it matched the end pattern
+	// and entryLast is when its last line was read
+	entryLines  int
+	entryClosed bool
+	entryLast   time.Time
+	// entryFinished is set once finishEntry ran for the newest entry
+	entryFinished bool
+	// idleTimer finishes the newest entry after the entry timeout
+	idleTimer *time.Timer
+	// pendingAlerts are the command alerts matched by lines of the newest entry, run once it's finished
+	pendingAlerts []*Alert
+
+	// sample holds the first lines while the entry pattern is detected
+	sample          []sampledLine
+	detected        bool
+	detectedPattern string
+}
+
+type sampledLine struct {
+	text string
+	time time.Time
+}
+
+func NewLogState(rateWindow time.Duration) *LogState {
+	return &LogState{Clusters: NewClusterer(), Rates: NewRateCounter(rateWindow)}
+}
+
+func (self *LogState) visible(entry *LogEntry) bool {
+	if self.filterRe != nil && !self.filterRe.MatchString(entry.Text) {
+		return false
+	}
+	return self.Filter == nil || self.Filter(entry)
+}
+
+// rules returns the entry rules of the log config, compiling them again only if they changed.
+func (self *LogState) rules(logConfig LogConfig) *entryRules {
+	logConfig = self.entryConfig(logConfig)
+	if self.entryRules == nil || !self.entryRules.compiledFrom(logConfig) {
+		self.entryRules = newEntryRules(logConfig)
+	}
+	return self.entryRules
+}
+
+// entryConfig replaces an auto entry pattern with the detected one.
+func (self *LogState) entryConfig(logConfig LogConfig) LogConfig {
+	if logConfig.EntryPattern == autoEntryPattern {
+		logConfig.EntryPattern = self.detectedPattern
+	}
+	return logConfig
+}
+
+const timeFormat = "15:04:05"
+
+func newLogEntry(text string, now time.Time) *LogEntry {
+	return &LogEntry{Text: text, FirstSeen: now, LastSeen: now, Count: 1}
+}
+
+// entryRow is the text shown for the entry in the log table.
+func entryRow(entry *LogEntry) string {
+	if entry.Count > 1 {
+		return fmt.Sprintf("\x1b[33m×%d\x1b[39m \x1b[37m%s–%s\x1b[39m %s",
+			entry.Count, entry.FirstSeen.Format(timeFormat), entry.LastSeen.Format(timeFormat), entry.Text)
+	}
+	return entry.Text
+}
+
+// entryViewRows is the text shown for the entry in the log view.
+// Expanded merged entries list all of their occurrences.
+func entryViewRows(entry *LogEntry, expanded bool) []string {
+	if !expanded || entry.Count < 2 {
+		return strings.Split(entry.Text, "\n")
+	}
+
+	rows := []string{}
+	for i, occurrence := range entry.Occurrences {
+		rows = append(rows, fmt.Sprintf("\x1b[33m── #%d %s ──\x1b[39m", i+1, occurrence.Time.Format(timeFormat)))
+		rows = append(rows, strings.Split(occurrence.Text, "\n")...)
+	}
+	return rows
+}
+
+func (self *LogEntry) merge(other *LogEntry) {
+	if self.Occurrences == nil {
+		self.Occurrences = []LogOccurrence{{Time: self.FirstSeen, Text: self.Text}}
+	}
+	self.Occurrences = append(self.Occurrences, LogOccurrence{Time: other.FirstSeen, Text: other.Text})
+	self.Count += other.Count
+	self.LastSeen = other.LastSeen
+}
+
+// addEntry puts a new entry on top of the log table, keeping the active row on the same entry.
+func addEntry(ctx *Context, index int, entry *LogEntry) {
+	state := ctx.LogStates[index]
+
+	entry.Cluster = state.Clusters.Add(entry.Text, entry.FirstSeen)
+	state.Rates.Add(entry.FirstSeen, detectLevel(entry.Text) >= LevelError)
+	state.Entries = append([]*LogEntry{entry}, state.Entries...)
+	updateNewestRow(ctx, index)
+}
+
+// appendToEntry adds a continuation line to the newest entry.
+func appendToEntry(ctx *Context, index int, line string) bool {
+	state := ctx.LogStates[index]
+	if len(state.Entries) == 0 {
+		return false
+	}
+	state.Entries[0].Text += "\n" + line
+	updateNewestRow(ctx, index)
+	return true
+}
+
+// updateNewestRow shows or hides the newest entry as it's matched by the filter, as lines are added
+// to it the filter is matched again on the whole text. The active row stays on the same entry.
+func updateNewestRow(ctx *Context, index int) {
+	state := ctx.LogStates[index]
+	logTable := ctx.LogTables[index]
+	entry := state.Entries[0]
+	shown := len(state.Visible) > 0 && state.Visible[0] == entry
+	visible := state.visible(entry)
+
+	switch {
+	case shown && visible:
+		logTable.Rows[0][0] = entryRow(entry)
+
+	case visible:
+		state.Visible = append([]*LogEntry{entry}, state.Visible...)
+		logTable.Rows = append([][]string{{entryRow(entry)}}, logTable.Rows...)
+		if ctx.Tabs.ActiveTabIndex == index && ctx.ActiveRow > -1 {
+			ctx.ActiveRow += 1
+			updateSelectedRowStyle(ctx)
+			logTable.ActiveRowIndex = ctx.ActiveRow
+		}
+
+	case shown:
+		hideNewestRow(ctx, index)
+	}
+}
+
+// hideNewestRow removes the row of the newest entry from the table.
+func hideNewestRow(ctx *Context, index int) {
+	state := ctx.LogStates[index]
+	logTable := ctx.LogTables[index]
+	state.Visible = state.Visible[1:]
+	logTable.Rows = logTable.Rows[1:]
+	if ctx.Tabs.ActiveTabIndex == index && ctx.ActiveRow > 0 {
+		ctx.ActiveRow -= 1
+		logTable.ActiveRowIndex = ctx.ActiveRow
+	}
+}
+
+// finishEntry is called when the newest entry is complete.
+// With dedupe enabled, it is merged into the previous one if they only differ in timestamps and numbers.
+func finishEntry(ctx *Context, index int) {
+	state := ctx.LogStates[index]
+	logTable := ctx.LogTables[index]
+
+	if state.entryFinished {
+		return
+	}
+	state.entryFinished = true
+	runPendingAlerts(ctx, index)
+
+	if !ctx.Config.Logs[index].Dedupe || len(state.Entries) < 2 {
+		return
+	}
+	last, prev := state.Entries[0], state.Entries[1]
+	if maskEntry(last.Text) != maskEntry(prev.Text) {
+		return
+	}
+
+	prev.merge(last)
+	state.Entries = state.Entries[1:]
+
+	if len(state.Visible) > 0 && state.Visible[0] == last {
+		hideNewestRow(ctx, index)
+	}
+	if len(state.Visible) > 0 && state.Visible[0] == prev {
+		logTable.Rows[0][0] = entryRow(prev)
+	}
+}
+
+// finishWhenIdle finishes the newest entry of the tab once no line was added to it for the timeout,
+// instead of when the next line comes.
+func finishWhenIdle(ctx *Context, index int, timeout time.Duration) {
+	state := ctx.LogStates[index]
+	if state.idleTimer != nil {
+		state.idleTimer.Stop()
+	}
+	var timer *time.Timer
+	timer = time.AfterFunc(timeout, func() {
+		ctx.tabsMu.Lock()
+		defer ctx.tabsMu.Unlock()
+
+		index := tabIndex(ctx, state)
+		// a line came or the tab was closed while waiting for the lock
+		if index == -1 || state.idleTimer != timer {
+			return
+		}
+		state.entryClosed = true
+		finishEntry(ctx, index)
+		if index == ctx.Tabs.ActiveTabIndex {
+			setViewText(ctx)
+			render(ctx.LogTables[index], rightPane(ctx))
+		}
+	})
+	state.idleTimer = timer
+}
+
+// setFilter rebuilds the table rows of a tab from the entries passing the filter.
+func setFilter(ctx *Context, index int, filter func(*LogEntry) bool) {
+	state := ctx.LogStates[index]
+	logTable := ctx.LogTables[index]
+
+	state.Filter = filter
+	state.Visible = []*LogEntry{}
+	rows := [][]string{}
+	for _, entry := range state.Entries {
+		if state.visible(entry) {
+			state.Visible = append(state.Visible, entry)
+			rows = append(rows, []string{entryRow(entry)})
+		}
+	}
+	logTable.Rows = rows
+
+	ctx.Tabs.TabNames[index] = tabTitle(ctx, index)
+	if ctx.Tabs.ActiveTabIndex == index {
+		ctx.ActiveRow = -1
+		logTable.ActiveRowIndex = ctx.ActiveRow
+		logTable.ScrollTop = 0
+	}
+}
+
+func tabTitle(ctx *Context, index int) string {
+	title := ctx.Config.Logs[index].Title
+	if ctx.LogStates[index].Filter != nil {
+		title += " (filtered)"
+	}
+	if ctx.LogStates[index].Ended {
+		title += " (ended)"
+	}
+	return title
+}
+
+func activeEntry(ctx *Context) *LogEntry {
+	state := ctx.LogStates[ctx.Tabs.ActiveTabIndex]
+	row := ctx.ActiveRow
+	if row == -1 {
+		row = 0
+	}
+	if row < len(state.Visible) {
+		return state.Visible[row]
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDedupeMergesConsecutiveEntries(t *testing.T) {
+	h := newHarness(t, LogConfig{Title: "api", EntryPattern: `^\d{4}-`, Dedupe: true})
+	h.feed(0,
+		"2024-05-01 12:00:00 ERROR timeout after 30ms",
+		"2024-05-01 12:00:01 ERROR timeout after 45ms",
+		"  at client.go:12",
+	)
+	state := h.ctx.LogStates[0]
+	// the newest entry may still get lines, it's merged once finished
+	if len(state.Entries) != 2 {
+		t.Fatalf("%d entries before the next one, want 2", len(state.Entries))
+	}
+
+	h.feed(0,
+		"2024-05-01 12:00:02 ERROR timeout after 51ms",
+		"  at client.go:12",
+		"2024-05-01 12:00:03 INFO retrying",
+		"2024-05-01 12:00:04 ERROR timeout after 60ms",
+	)
+	want := []string{"2024-05-01 12:00:04 ERROR timeout after 60ms", "2024-05-01 12:00:03 INFO retrying",
+		"2024-05-01 12:00:01 ERROR timeout after 45ms", "2024-05-01 12:00:00 ERROR timeout after 30ms"}
+	if got := visibleTexts(state); !slices.Equal(got, want) {
+		t.Fatalf("entries %q, want %q", got, want)
+	}
+	merged := state.Entries[2]
+	if merged.Count != 2 || len(merged.Occurrences) != 2 || merged.Occurrences[1].Text != "2024-05-01 12:00:02 ERROR timeout after 51ms\n  at client.go:12" {
+		t.Errorf("merged entry has count %d and occurrences %v", merged.Count, merged.Occurrences)
+	}
+	if got := h.ctx.LogTables[0].Rows[2][0]; got != entryRow(merged) {
+		t.Errorf("row of the merged entry is %q", got)
+	}
+	// the newest duplicate isn't consecutive, the oldest one has no continuation line
+	if state.Entries[0].Count != 1 || state.Entries[3].Count != 1 {
+		t.Errorf("entries that aren't duplicates were merged")
+	}
+}
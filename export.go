@@ -0,0 +1,188 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	ui "github.com/gizak/termui/v3"
+	customWidgets "replika.com/log-reader/widgets"
+)
+
+type ExportFormat string
+
+const (
+	ExportRaw   ExportFormat = "raw"
+	ExportJSONL ExportFormat = "jsonl"
+	ExportCSV   ExportFormat = "csv"
+)
+
+var unsafeFileNameRe = regexp.MustCompile(`[^\w.-]+`)
+
+func exportFormat(path string) ExportFormat {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".jsonl", ".json", ".ndjson":
+		return ExportJSONL
+	case ".csv":
+		return ExportCSV
+	}
+	return ExportRaw
+}
+
+// entryColumns parses an entry into columns: arrival time, repeat count, level,
+// named groups of the entry pattern and the whole message.
+// With several entry patterns, the groups of all of them are columns, filled from the one matching the entry.
+func entryColumns(rules *entryRules, entry *LogEntry) ([]string, []string) {
+	text := customWidgets.StripAsciiCodes(entry.Text)
+
+	names := []string{"time", "count", "level"}
+	values := []string{entry.FirstSeen.Format(time.RFC3339Nano), strconv.Itoa(entry.Count), detectLevel(text).String()}
+
+	columns := map[string]int{}
+	startRe := rules.startRe(firstLine(text))
+	for _, entryRe := range rules.startRes {
+		var match []string
+		if entryRe == startRe {
+			match = entryRe.FindStringSubmatch(firstLine(text))
+		}
+		for i, name := range entryRe.SubexpNames() {
+			if i == 0 || name == "" {
+				continue
+			}
+			column, ok := columns[name]
+			if !ok {
+				column = len(names)
+				columns[name] = column
+				names = append(names, name)
+				values = append(values, "")
+			}
+			if match != nil {
+				values[column] = match[i]
+			}
+		}
+	}
+
+	names = append(names, "message")
+	values = append(values, text)
+	return names, values
+}
+
+// exportEntries writes entries, given newest first, in chronological order.
+func exportEntries(w io.Writer, format ExportFormat, rules *entryRules, entries []*LogEntry) error {
+	csvWriter := csv.NewWriter(w)
+	encoder := json.NewEncoder(w)
+
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+
+		switch format {
+		case ExportJSONL:
+			names, values := entryColumns(rules, entry)
+			object := map[string]string{}
+			for j, name := range names {
+				object[name] = values[j]
+			}
+			if err := encoder.Encode(object); err != nil {
+				return err
+			}
+
+		case ExportCSV:
+			names, values := entryColumns(rules, entry)
+			if i == len(entries)-1 {
+				if err := csvWriter.Write(names); err != nil {
+					return err
+				}
+			}
+			if err := csvWriter.Write(values); err != nil {
+				return err
+			}
+
+		default:
+			if _, err := fmt.Fprintln(w, customWidgets.StripAsciiCodes(entry.Text)); err != nil {
+				return err
+			}
+		}
+	}
+
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+// expandHome replaces a leading ~/ with the home directory.
+func expandHome(path string) string {
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[2:])
+		}
+	}
+	return path
+}
+
+// writeExport writes the entries to a new file, an existing one is only replaced with overwrite.
+func writeExport(path string, overwrite bool, rules *entryRules, entries []*LogEntry) error {
+	flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if overwrite {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	file, err := os.OpenFile(expandHome(path), flags, 0644)
+	if err != nil {
+		return err
+	}
+	if err := exportEntries(file, exportFormat(path), rules, entries); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// startExport asks for a file path and exports the entries of the current tab:
+// the marked ones if there are any, otherwise the visible (filtered) ones, or all of them.
+func startExport(ctx *Context, all bool) {
+	index := ctx.Tabs.ActiveTabIndex
+	state := ctx.LogStates[index]
+
+	entries := markedEntries(state)
+	if len(entries) == 0 {
+		entries = state.Visible
+	}
+	if all {
+		entries = state.Entries
+	}
+	entries = append([]*LogEntry{}, entries...)
+
+	rules := newEntryRules(state.entryConfig(ctx.Config.Logs[index]))
+	defaultPath := unsafeFileNameRe.ReplaceAllString(strings.ToLower(ctx.Config.Logs[index].Title), "-") + ".log"
+
+	label := fmt.Sprintf("Export %d entries to (.log, .jsonl, .csv):", len(entries))
+	showPrompt(ctx, label, defaultPath, func(path string) {
+		if path == "" {
+			return
+		}
+		exportTo(ctx, path, false, rules, entries)
+	})
+}
+
+func exportTo(ctx *Context, path string, overwrite bool, rules *entryRules, entries []*LogEntry) {
+	err := writeExport(path, overwrite, rules, entries)
+	switch {
+	case errors.Is(err, fs.ErrExist):
+		showPrompt(ctx, fmt.Sprintf("%s exists, overwrite it? (y/n)", path), "", func(answer string) {
+			if strings.EqualFold(answer, "y") || strings.EqualFold(answer, "yes") {
+				exportTo(ctx, path, true, rules, entries)
+			}
+		})
+	case err != nil:
+		flashInfo(ctx, fmt.Sprintf("Export failed: %v", err), alertInfoStyle)
+	default:
+		flashInfo(ctx, fmt.Sprintf("Exported %d entries to [%s](fg:cyan)", len(entries), path), ui.Theme.Block.Border)
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// typeText presses the keys of the text, as typed in a prompt.
+func (self *harness) typeText(text string) {
+	for _, char := range text {
+		self.press(string(char))
+	}
+}
+
+func TestExportAsksBeforeOverwriting(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api.log")
+	if err := os.WriteFile(path, []byte("kept\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	h := newHarness(t, LogConfig{Title: "api", EntryPattern: `^\d{4}-`})
+	h.feed(0, harnessLines...)
+
+	export := func(answer string) string {
+		h.press("x", "<C-u>")
+		h.typeText(path)
+		h.press("<Enter>")
+		h.typeText(answer)
+		h.press("<Enter>")
+		got, _ := os.ReadFile(path)
+		return string(got)
+	}
+	if got := export("n"); got != "kept\n" {
+		t.Errorf("the file was overwritten without confirmation: %q", got)
+	}
+	if got := export("y"); !strings.HasPrefix(got, harnessLines[0]+"\n") {
+		t.Errorf("the file wasn't overwritten: %q", got)
+	}
+}
+
+func TestEntryColumnsSeveralPatterns(t *testing.T) {
+	rules := newEntryRules(LogConfig{
+		EntryPattern:  `^(?P<date>\S+) (?P<thread>\[\w+\])`,
+		EntryPatterns: []string{`^(?P<date>\d+)\|(?P<pid>\d+)\|`},
+	})
+	now := harnessTime
+
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"2024-05-01 [main] INFO started", []string{"2024-05-01", "[main]", ""}},
+		{"1714564800|42|ERROR failed", []string{"1714564800", "", "42"}},
+		{"no header", []string{"", "", ""}},
+	}
+	for _, test := range tests {
+		names, values := entryColumns(rules, newLogEntry(test.text, now))
+		if want := []string{"time", "count", "level", "date", "thread", "pid", "message"}; !reflect.DeepEqual(names, want) {
+			t.Fatalf("got columns %q, want %q", names, want)
+		}
+		if got := values[3:6]; !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q: got groups %q, want %q", test.text, got, test.want)
+		}
+		if values[6] != test.text {
+			t.Errorf("%q: got message %q", test.text, values[6])
+		}
+	}
+}
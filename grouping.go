@@ -0,0 +1,137 @@
+package main
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// defaultMaxEntryLines is used when max_entry_lines isn't set.
+const defaultMaxEntryLines = 1000
+
+// entryRules decide which lines start and end the entries of a log.
+type entryRules struct {
+	startRes []*regexp.Regexp
+	endRe    *regexp.Regexp
+	maxLines int
+	// timeout completes an entry when no line was added to it for that long, 0 to wait forever
+	timeout time.Duration
+
+	// the config the rules were compiled from
+	patterns      []string
+	endPattern    string
+	timeoutConfig string
+}
+
+// newEntryRules compiles the entry patterns of the log, invalid ones are reported by validateConfig and skipped.
+func newEntryRules(logConfig LogConfig) *entryRules {
+	self := &entryRules{
+		maxLines:      logConfig.MaxEntryLines,
+		patterns:      entryPatterns(logConfig),
+		endPattern:    logConfig.EntryEndPattern,
+		timeoutConfig: logConfig.EntryTimeout,
+	}
+	if self.timeoutConfig != "" {
+		self.timeout, _ = time.ParseDuration(self.timeoutConfig)
+	}
+	if self.maxLines == 0 {
+		self.maxLines = defaultMaxEntryLines
+	}
+	for _, pattern := range self.patterns {
+		if re, err := regexp.Compile(pattern); err == nil {
+			self.startRes = append(self.startRes, re)
+		}
+	}
+	if self.endPattern != "" {
+		self.endRe, _ = regexp.Compile(self.endPattern)
+	}
+	return self
+}
+
+// entryPatterns are the non-empty patterns of entry_pattern and entry_patterns.
+func entryPatterns(logConfig LogConfig) []string {
+	patterns := []string{}
+	for _, pattern := range append([]string{logConfig.EntryPattern}, logConfig.EntryPatterns...) {
+		if pattern != "" {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
+
+func (self *entryRules) compiledFrom(logConfig LogConfig) bool {
+	maxLines := logConfig.MaxEntryLines
+	if maxLines == 0 {
+		maxLines = defaultMaxEntryLines
+	}
+	return self.maxLines == maxLines && self.endPattern == logConfig.EntryEndPattern && self.timeoutConfig == logConfig.EntryTimeout &&
+		slices.Equal(self.patterns, entryPatterns(logConfig))
+}
+
+// startsEntry reports whether the line starts a new entry. lines is the number of lines of the current entry,
+// closed is set if it's complete. A line without a current entry starts one, even without a header.
+func (self *entryRules) startsEntry(line string, lines int, closed bool) bool {
+	if closed || lines == 0 || lines >= self.maxLines {
+		return true
+	}
+	if len(self.startRes) == 0 {
+		// without start patterns, every line is an entry, or entries go until the end pattern
+		return self.endRe == nil
+	}
+	return self.startRe(line) != nil
+}
+
+// idle reports whether the entry which last line came at last is complete at now.
+func (self *entryRules) idle(last time.Time, now time.Time) bool {
+	return self.timeout > 0 && now.Sub(last) >= self.timeout
+}
+
+// startRe returns the first start pattern matching the line, or nil.
+func (self *entryRules) startRe(line string) *regexp.Regexp {
+	for _, re := range self.startRes {
+		if re.MatchString(line) {
+			return re
+		}
+	}
+	return nil
+}
+
+// ends reports whether the line is the last one of its entry.
+func (self *entryRules) ends(line string) bool {
+	return self.endRe != nil && self.endRe.MatchString(line)
+}
+
+// entryGrouper collects lines into whole entries, for reading logs without the UI.
+type entryGrouper struct {
+	rules  *entryRules
+	lines  []string
+	closed bool
+	last   time.Time
+}
+
+// add returns the previous entry when the line, read at now, starts a new one.
+func (self *entryGrouper) add(line string, now time.Time) (string, bool) {
+	closed := self.closed || self.rules.idle(self.last, now)
+	self.last = now
+	if !self.rules.startsEntry(line, len(self.lines), closed) {
+		self.lines = append(self.lines, line)
+		self.closed = self.rules.ends(line)
+		return "", false
+	}
+	entry, ok := self.flush()
+	self.lines = []string{strings.TrimSpace(line)}
+	self.closed = self.rules.ends(line)
+	return entry, ok
+}
+
+// flush returns the last entry, when the input ended or the entry timed out.
+func (self *entryGrouper) flush() (string, bool) {
+	if len(self.lines) == 0 {
+		return "", false
+	}
+	entry := strings.Join(self.lines, "\n")
+	self.lines = nil
+	self.closed = false
+	return entry, true
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestEntryGrouper(t *testing.T) {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	type line struct {
+		text  string
+		delay time.Duration
+	}
+	tests := []struct {
+		name      string
+		logConfig LogConfig
+		lines     []line
+		want      []string
+	}{
+		{
+			name:      "every line without patterns",
+			logConfig: LogConfig{},
+			lines:     []line{{"a", 0}, {"b", 0}},
+			want:      []string{"a", "b"},
+		},
+		{
+			name:      "lines before the first header",
+			logConfig: LogConfig{EntryPattern: `^\d`},
+			lines:     []line{{"preamble", 0}, {"more", 0}, {"1 first", 0}, {"  cont", 0}},
+			want:      []string{"preamble\nmore", "1 first\n  cont"},
+		},
+		{
+			name:      "several start patterns",
+			logConfig: LogConfig{EntryPattern: `^\d`, EntryPatterns: []string{`^Traceback`}},
+			lines:     []line{{"1 first", 0}, {"Traceback", 0}, {"  File x", 0}, {"2 second", 0}},
+			want:      []string{"1 first", "Traceback\n  File x", "2 second"},
+		},
+		{
+			name:      "end pattern",
+			logConfig: LogConfig{EntryEndPattern: `^END`},
+			lines:     []line{{"a", 0}, {"END", 0}, {"b", 0}, {"c", 0}, {"END", 0}},
+			want:      []string{"a\nEND", "b\nc\nEND"},
+		},
+		{
+			name:      "max lines",
+			logConfig: LogConfig{EntryPattern: `^\d`, MaxEntryLines: 2},
+			lines:     []line{{"1 first", 0}, {"a", 0}, {"b", 0}, {"c", 0}},
+			want:      []string{"1 first\na", "b\nc"},
+		},
+		{
+			name:      "idle timeout",
+			logConfig: LogConfig{EntryPattern: `^\d`, EntryTimeout: "1s"},
+			lines:     []line{{"1 first", 0}, {"a", time.Millisecond * 500}, {"b", time.Second}, {"c", 0}},
+			want:      []string{"1 first\na", "b\nc"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			grouper := &entryGrouper{rules: newEntryRules(test.logConfig)}
+			now := start
+			got := []string{}
+			for _, line := range test.lines {
+				now = now.Add(line.delay)
+				if entry, ok := grouper.add(line.text, now); ok {
+					got = append(got, entry)
+				}
+			}
+			if entry, ok := grouper.flush(); ok {
+				got = append(got, entry)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got entries %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+// entryTexts are the texts of the entries of the tab, oldest first.
+func entryTexts(state *LogState) []string {
+	texts := []string{}
+	for i := len(state.Entries) - 1; i >= 0; i-- {
+		texts = append(texts, state.Entries[i].Text)
+	}
+	return texts
+}
+
+func TestTabEntryRules(t *testing.T) {
+	tests := []struct {
+		name      string
+		logConfig LogConfig
+		lines     []string
+		want      []string
+	}{
+		{
+			name:      "several start patterns",
+			logConfig: LogConfig{EntryPattern: `^\d`, EntryPatterns: []string{`^Traceback`}},
+			lines:     []string{"1 first", "Traceback", "  File x", "2 second"},
+			want:      []string{"1 first", "Traceback\n  File x", "2 second"},
+		},
+		{
+			name:      "end pattern",
+			logConfig: LogConfig{EntryEndPattern: `^END`},
+			lines:     []string{"a", "END", "b", "c", "END", "d"},
+			want:      []string{"a\nEND", "b\nc\nEND", "d"},
+		},
+		{
+			name:      "start and end patterns",
+			logConfig: LogConfig{EntryPattern: `^BEGIN`, EntryEndPattern: `^END`},
+			lines:     []string{"BEGIN 1", "a", "END", "stray", "BEGIN 2", "b"},
+			want:      []string{"BEGIN 1\na\nEND", "stray", "BEGIN 2\nb"},
+		},
+		{
+			name:      "max lines",
+			logConfig: LogConfig{EntryPattern: `^\d`, MaxEntryLines: 2},
+			lines:     []string{"1 first", "a", "b", "c", "d"},
+			want:      []string{"1 first\na", "b\nc", "d"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.logConfig.Title = "api"
+			h := newHarness(t, test.logConfig)
+			h.feed(0, test.lines...)
+			if got := entryTexts(h.ctx.LogStates[0]); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got entries %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestIdleEntryFinishedWithoutNextLine(t *testing.T) {
+	h := newHarness(t, LogConfig{Title: "api", EntryPattern: `^\d`, EntryTimeout: "20ms", Dedupe: true})
+	h.feed(0, "1 job 1 done", "2 job 2 done")
+
+	// the second entry is merged into the first once idle, without waiting for a third line
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		h.ctx.tabsMu.Lock()
+		entries := len(h.ctx.LogStates[0].Entries)
+		h.ctx.tabsMu.Unlock()
+		if entries == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d entries, the idle entry wasn't finished", entries)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// a line after the timeout starts a new entry, the finished one isn't finished again
+	h.feed(0, "  stray")
+	if got := entryTexts(h.ctx.LogStates[0]); !reflect.DeepEqual(got, []string{"1 job 1 done", "stray"}) {
+		t.Errorf("got entries %q", got)
+	}
+}
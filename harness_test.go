@@ -0,0 +1,335 @@
+package main
+
+import (
+	"flag"
+	"image"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	ui "github.com/gizak/termui/v3"
+	customWidgets "replika.com/log-reader/widgets"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// harness runs the reader without a terminal: keys are sent as events and widgets are drawn into a buffer.
+type harness struct {
+	t      *testing.T
+	ctx    *Context
+	screen *ui.Buffer
+	events chan ui.Event
+}
+
+var harnessTime = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func newHarness(t *testing.T, logs ...LogConfig) *harness {
+	self := &harness{
+		t:      t,
+		screen: ui.NewBuffer(image.Rect(0, 0, 80, 24)),
+		events: make(chan ui.Event),
+	}
+	drawItems = func(items ...ui.Drawable) {
+		for _, item := range items {
+			buf := ui.NewBuffer(item.GetRect())
+			item.Lock()
+			item.Draw(buf)
+			item.Unlock()
+			for point, cell := range buf.CellMap {
+				if point.In(buf.Rectangle) && point.In(self.screen.Rectangle) {
+					self.screen.SetCell(cell, point)
+				}
+			}
+		}
+	}
+	terminalDimensions = func() (int, int) {
+		return self.screen.Dx(), self.screen.Dy()
+	}
+	t.Cleanup(func() {
+		// timers and listeners left by the test find no tab and no message to draw
+		self.ctx.tabsMu.Lock()
+		for _, state := range self.ctx.LogStates {
+			stopLog(state)
+			if state.idleTimer != nil {
+				state.idleTimer.Stop()
+			}
+		}
+		self.ctx.LogStates = nil
+		self.ctx.tabsMu.Unlock()
+		self.ctx.infoMu.Lock()
+		self.ctx.infoGeneration++
+		self.ctx.infoMu.Unlock()
+
+		drawItems = ui.Render
+		terminalDimensions = ui.TerminalDimensions
+	})
+
+	// tabs are added without starting their sources, lines are fed by the test
+	self.ctx = newContext(&Config{Logs: logs})
+	for _, logConfig := range logs {
+		addTab(self.ctx, logConfig, true)
+	}
+	updateGridLayout(self.ctx)
+	render(self.ctx.Tabs, self.ctx.Grid, self.ctx.Info)
+
+	go listenKeys(self.ctx, self.events, make(chan bool, 1))
+	return self
+}
+
+// feed adds lines to the tab, as if read from its log.
+func (self *harness) feed(index int, lines ...string) {
+	state := self.ctx.LogStates[index]
+	for _, line := range lines {
+		ingestLine(self.ctx, state, state.generation, line, harnessTime, true)
+	}
+}
+
+// fakeSource is a log the test writes to. Its lines go through openSource and listenLog, like the
+// output of a command.
+type fakeSource struct {
+	h     *harness
+	state *LogState
+	lines *lineSource
+}
+
+// open opens a tab reading a fake source.
+func (self *harness) open(logConfig LogConfig) *fakeSource {
+	lines := newLineSource()
+	logConfig.source = lines
+	var index int
+	self.run(func() { index = openTab(self.ctx, logConfig, true) })
+	return &fakeSource{h: self, state: self.ctx.LogStates[index], lines: lines}
+}
+
+func (self *fakeSource) write(lines ...string) {
+	for _, line := range lines {
+		self.lines.add([]byte(line+"\n"), harnessTime)
+	}
+}
+
+// end ends the source, and waits until the listener read all lines and marked the tab as ended.
+func (self *fakeSource) end() {
+	self.lines.end(io.EOF)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		self.h.ctx.tabsMu.Lock()
+		ended := self.state.Ended
+		self.h.ctx.tabsMu.Unlock()
+		if ended {
+			return
+		}
+		if time.Now().After(deadline) {
+			self.h.t.Fatal("the listener didn't end")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// run runs the function on the key listener goroutine, like an action, and waits until it's done.
+func (self *harness) run(action func()) {
+	self.ctx.Actions <- action
+	self.ctx.Actions <- func() {}
+}
+
+// press sends the keys and waits until they're handled.
+func (self *harness) press(ids ...string) {
+	for _, id := range ids {
+		self.events <- ui.Event{Type: ui.KeyboardEvent, ID: id}
+	}
+	self.ctx.Actions <- func() {}
+}
+
+func (self *harness) assertScreen(name string) {
+	self.t.Helper()
+	renderMu.Lock()
+	got := customWidgets.BufferText(self.screen)
+	renderMu.Unlock()
+
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+			self.t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		self.t.Fatalf("%v, run with -update to create it", err)
+	}
+	if got != string(want) {
+		self.t.Errorf("%s differs from the snapshot:\n--- got\n%s--- want\n%s", name, got, want)
+	}
+}
+
+var harnessLines = []string{
+	"2024-05-01 12:00:00 INFO starting server",
+	"2024-05-01 12:00:01 WARN slow request",
+	"  took 2300ms",
+	"2024-05-01 12:00:02 ERROR request failed",
+	"  at handler.go:42",
+	"  at server.go:7",
+	"2024-05-01 12:00:03 INFO done",
+}
+
+func TestHarnessNavigation(t *testing.T) {
+	h := newHarness(t,
+		LogConfig{Title: "api", EntryPattern: `^\d{4}-`},
+		LogConfig{Title: "worker"},
+	)
+	h.feed(0, harnessLines...)
+	h.feed(1, "job 1 started", "job 1 finished")
+	h.assertScreen("harness_fed")
+
+	h.press("<Down>", "<Down>")
+	h.assertScreen("harness_selected")
+
+	h.press("e")
+	h.assertScreen("harness_expanded")
+
+	h.press("<Right>")
+	h.assertScreen("harness_second_tab")
+
+	h.press("<Left>", "l")
+	h.assertScreen("harness_list_hidden")
+}
+
+func TestHarnessPatterns(t *testing.T) {
+	h := newHarness(t, LogConfig{Title: "api", EntryPattern: `^\d{4}-`})
+	h.feed(0, harnessLines...)
+	h.feed(0, "2024-05-01 12:00:04 INFO done")
+
+	h.press("p")
+	h.assertScreen("harness_patterns")
+}
+
+func TestHarnessDetectedPattern(t *testing.T) {
+	h := newHarness(t, LogConfig{Title: "api", EntryPattern: autoEntryPattern})
+	h.feed(0, harnessLines...)
+	state := h.ctx.LogStates[0]
+	endLog(h.ctx, state, state.generation)
+	h.assertScreen("harness_detected")
+
+	// the pattern stays in the Info bar once the message is gone
+	renderInfo(h.ctx)
+	h.assertScreen("harness_detected_status")
+
+	// a restarted log is detected again
+	logConfig := h.ctx.Config.Logs[0]
+	logConfig.Command = "other"
+	logConfig.source = newLineSource()
+	h.run(func() { updateTab(h.ctx, 0, logConfig) })
+	if state.detected || state.detectedPattern != "" || h.ctx.infoStatus != "" {
+		t.Errorf("the detected pattern was kept on restart")
+	}
+}
+
+func TestHarnessStackTrace(t *testing.T) {
+	h := newHarness(t, LogConfig{Title: "api", EntryPattern: `^\d{4}-`})
+	h.feed(0, javaTrace...)
+	h.assertScreen("harness_trace_folded")
+
+	h.press("s")
+	h.assertScreen("harness_trace_expanded")
+}
+
+func TestHarnessPayload(t *testing.T) {
+	h := newHarness(t, LogConfig{Title: "api", EntryPattern: `^\d{4}-`})
+	h.feed(0, `2024-05-01 12:00:00 INFO sent payload={"user":{"id":7,"name":"Ann"},"items":[1,2]} in 3ms`)
+	h.assertScreen("harness_payload_pretty")
+
+	h.press("f")
+	h.assertScreen("harness_payload_raw")
+}
+
+func TestHarnessNoWrap(t *testing.T) {
+	h := newHarness(t, LogConfig{Title: "api", EntryPattern: `^\d{4}-`}, LogConfig{Title: "worker"})
+	widest := "  1 | SIMPLE      | orders | ref  | idx_customer  | idx_cus |   42 | Using where"
+	h.feed(0,
+		"2024-05-01 12:00:00 INFO query plan",
+		" id | select_type | table  | type | possible_keys | key     | rows | Extra",
+		widest,
+	)
+	h.press("<Tab>", "z")
+	h.assertScreen("harness_nowrap")
+
+	h.press("<Right>", "<Right>", "<Right>")
+	h.assertScreen("harness_nowrap_scrolled")
+
+	// the offset stops at the end of the widest row
+	h.press("<Right>", "<Right>", "<Right>", "<Right>", "<Right>")
+	if offset := h.ctx.LogView.ColumnOffset; offset != len(widest)-h.ctx.LogView.Inner.Dx() {
+		t.Errorf("scrolled to column %d", offset)
+	}
+	if h.ctx.Tabs.ActiveTabIndex != 0 {
+		t.Errorf("switched to tab %d while scrolling", h.ctx.Tabs.ActiveTabIndex)
+	}
+
+	// without the list, the column is shown in the Info bar once the message is gone
+	h.press("l", "<Left>")
+	renderInfo(h.ctx)
+	h.assertScreen("harness_nowrap_list_hidden")
+
+	h.press("l", "<Tab>", "<Right>")
+	if h.ctx.Tabs.ActiveTabIndex != 1 {
+		t.Errorf("Right in the log list didn't switch tabs")
+	}
+}
+
+func TestHarnessSource(t *testing.T) {
+	h := newHarness(t, LogConfig{Title: "worker"})
+	source := h.open(LogConfig{Title: "api", EntryPattern: `^\d{4}-`})
+	source.write(harnessLines...)
+	source.end()
+
+	h.press("<Right>")
+	h.assertScreen("harness_source")
+}
+
+func TestKeysWhileReading(t *testing.T) {
+	h := newHarness(t, LogConfig{Title: "worker"})
+	source := h.open(LogConfig{Title: "api", EntryPattern: `^\d{4}-`})
+	h.press("<Right>")
+
+	done := make(chan bool)
+	go func() {
+		defer close(done)
+		for i := 0; i < 200; i++ {
+			source.write(harnessLines...)
+		}
+	}()
+	// run with -race, the listener adds rows while the keys move, mark and show them
+	for i := 0; i < 50; i++ {
+		h.press("<Down>", "<Space>", "e", "<Down>", "v", "<Up>", "<Escape>", "<Tab>", "<Down>", "<Tab>", "s", "f", "z", "u")
+	}
+	<-done
+	source.end()
+
+	h.ctx.tabsMu.Lock()
+	defer h.ctx.tabsMu.Unlock()
+	if got := len(source.state.Entries); got == 0 {
+		t.Errorf("no entries were read")
+	}
+}
+
+func TestHarnessHelp(t *testing.T) {
+	h := newHarness(t, LogConfig{Title: "api"})
+	h.ctx.Config.Pipes = []PipeConfig{{Title: "jq", Key: "j", Command: "jq ."}, {Command: "wc -l"}}
+	h.feed(0, "started")
+	h.press("l", "?")
+	h.assertScreen("harness_help")
+
+	h.press("q")
+	if h.ctx.OutputShown {
+		t.Errorf("q didn't close the keys")
+	}
+}
+
+func TestListenLogClosedTab(t *testing.T) {
+	h := newHarness(t, LogConfig{Title: "api", Command: "echo"}, LogConfig{Title: "worker", Command: "echo"})
+	state := h.ctx.LogStates[0]
+	h.run(func() { closeTab(h.ctx, 0) })
+	// the listener of a tab closed before it started returns without reading
+	listenLog(h.ctx, state)
+}
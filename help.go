@@ -0,0 +1,55 @@
+package main
+
+import "fmt"
+
+// keyHelp lists the keys shown by ?, in the order of the README.
+var keyHelp = [][2]string{
+	{"?", "show this list, Escape or q closes it"},
+	{"q", "quit"},
+	{"Tab", "switch between the log list and the log view"},
+	{"Left/Right", "switch tabs, or scroll the log view when it's active and lines aren't wrapped"},
+	{"Up/Down", "select an entry, or scroll the log view"},
+	{"Escape", "unselect the entry, or end visual mode"},
+	{"l", "show/hide the log list"},
+	{"e", "expand repeated entries"},
+	{"s", "show/fold library frames of stack traces"},
+	{"f", "show payloads raw or pretty-printed"},
+	{"z", "wrap long lines or scroll them"},
+	{"p", "show patterns, Enter filters by the selected one"},
+	{"r", "show/hide rates"},
+	{"Space", "mark the selected entry"},
+	{"J/K", "extend marks down/up (shift+arrows aren't reported by termbox)"},
+	{"v", "visual mode, marking the rows between the start and the selection"},
+	{"u", "clear marks"},
+	{"Ctrl+C", "copy the marked or selected entries, or the selected line of the log view"},
+	{"x/X", "export the marked or visible entries / all entries"},
+	{"|", "pipe the marked or selected entries to a command"},
+	{"o/O", "open the marked or selected entries in $EDITOR/$PAGER"},
+	{"t/w", "open/close a tab"},
+	{"d", "duplicate the tab with a filter"},
+	{"n", "rename the tab"},
+	{"W", "save the tabs to the config"},
+	{"P/>", "pause/change the speed of a replay"},
+}
+
+// showHelp lists the keys in the output view.
+func showHelp(ctx *Context) {
+	rows := []string{}
+	for _, key := range keyHelp {
+		rows = append(rows, fmt.Sprintf("\x1b[33m%-11s\x1b[39m %s", key[0], key[1]))
+	}
+	for _, pipe := range ctx.Config.Pipes {
+		if pipe.Key == "" {
+			continue
+		}
+		title := pipe.Title
+		if title == "" {
+			title = "| " + pipe.Command
+		}
+		rows = append(rows, fmt.Sprintf("\x1b[33m%-11s\x1b[39m %s", pipe.Key, title))
+	}
+	ctx.OutputView.Title = " Keys "
+	ctx.OutputView.Rows = rows
+	ctx.OutputView.SelectedRow = 0
+	showOutput(ctx)
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+type Level int
+
+const (
+	LevelUnknown Level = iota
+	LevelTrace
+	LevelDebug
+	LevelInfo
+	LevelWarn
+	LevelError
+	LevelFatal
+)
+
+var levelNames = map[string]Level{
+	"TRACE":    LevelTrace,
+	"DEBUG":    LevelDebug,
+	"INFO":     LevelInfo,
+	"NOTICE":   LevelInfo,
+	"WARN":     LevelWarn,
+	"WARNING":  LevelWarn,
+	"ERR":      LevelError,
+	"ERROR":    LevelError,
+	"CRIT":     LevelFatal,
+	"CRITICAL": LevelFatal,
+	"FATAL":    LevelFatal,
+	"PANIC":    LevelFatal,
+}
+
+var levelTitles = []string{"", "TRACE", "DEBUG", "INFO", "WARN", "ERROR", "FATAL"}
+
+func (self Level) String() string {
+	return levelTitles[self]
+}
+
+// matches "level=error", "\"level\":\"error\"" as well as bare upper-case level names
+var levelRe = regexp.MustCompile(`(?i:\blevel"?\s*[=:]\s*"?(\w+))|\b(TRACE|DEBUG|INFO|NOTICE|WARN|WARNING|ERR|ERROR|CRIT|CRITICAL|FATAL|PANIC)\b`)
+
+func parseLevel(name string) Level {
+	return levelNames[strings.ToUpper(strings.TrimSpace(name))]
+}
+
+// detectLevel looks for a log level in the first line of an entry.
+func detectLevel(text string) Level {
+	if idx := strings.IndexByte(text, '\n'); idx > -1 {
+		text = text[:idx]
+	}
+	match := levelRe.FindStringSubmatch(text)
+	if match == nil {
+		return LevelUnknown
+	}
+	if match[1] != "" {
+		return parseLevel(match[1])
+	}
+	return parseLevel(match[2])
+}
@@ -5,12 +5,12 @@ import (
 	"fmt"
 	"log"
 	"os"
-	"os/exec"
-	"regexp"
+	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/atotto/clipboard"
 	ui "github.com/gizak/termui/v3"
 	"github.com/gizak/termui/v3/widgets"
 	tb "github.com/nsf/termbox-go"
@@ -26,13 +26,37 @@ const (
 )
 
 type LogConfig struct {
-	Title string `mapstructure:"title"`
-	Command string `mapstructure:"command"`
-	EntryPattern string `mapstructure:"entry_pattern"`
+	Title string `mapstructure:"title" yaml:"title"`
+	Command string `mapstructure:"command" yaml:"command"`
+	EntryPattern string `mapstructure:"entry_pattern" yaml:"entry_pattern,omitempty"`
+	// EntryPatterns are more patterns starting an entry, for logs mixing formats
+	EntryPatterns []string `mapstructure:"entry_patterns" yaml:"entry_patterns,omitempty"`
+	// EntryEndPattern matches the last line of an entry
+	EntryEndPattern string `mapstructure:"entry_end_pattern" yaml:"entry_end_pattern,omitempty"`
+	// MaxEntryLines starts a new entry when one gets longer, defaultMaxEntryLines if not set
+	MaxEntryLines int `mapstructure:"max_entry_lines" yaml:"max_entry_lines,omitempty"`
+	// EntryTimeout is a duration after which an entry without new lines is complete
+	EntryTimeout string `mapstructure:"entry_timeout" yaml:"entry_timeout,omitempty"`
+	Dedupe bool `mapstructure:"dedupe" yaml:"dedupe,omitempty"`
+	// File is followed instead of running a command
+	File string `mapstructure:"file" yaml:"file,omitempty"`
+	// Stdin reads the log from the standard input instead of a command
+	Stdin bool `mapstructure:"stdin" yaml:"stdin,omitempty"`
+	// Filter hides entries not matching the regexp
+	Filter string `mapstructure:"filter" yaml:"filter,omitempty"`
+	// source feeds the log instead of a command, like a replayed recording
+	source *lineSource
 }
 
 type Config struct {
 	Logs []LogConfig `mapstructure:"logs"`
+	Alerts []AlertConfig `mapstructure:"alerts"`
+	RateWindow time.Duration `mapstructure:"rate_window"`
+	Pipes []PipeConfig `mapstructure:"pipes"`
+	Clipboard ClipboardConfig `mapstructure:"clipboard"`
+	Params []ParamConfig `mapstructure:"params"`
+	Profiles []ProfileConfig `mapstructure:"profiles"`
+	StackTraces StackTraceConfig `mapstructure:"stack_traces"`
 }
 
 type Context struct {
@@ -43,13 +67,53 @@ type Context struct {
 	Grid *ui.Grid
 	Tabs *widgets.TabPane
 	LogTables []*customWidgets.RawTable
+	LogStates []*LogState
 	LogView *customWidgets.List
-	Info *widgets.Paragraph
+	PatternView *customWidgets.List
+	OutputView *customWidgets.List
+	Info *customWidgets.Paragraph
+	RateView *customWidgets.SparklineGroup
 	LogTableCell ui.GridItem
 	LeftHidden bool
 	RightHidden bool
+	Expanded bool
+	PatternsShown bool
+	FramesExpanded bool
+	RawPayloads bool
+	RatesShown bool
+	OutputShown bool
+	Alerts []*Alert
+	Prompt *Prompt
+	VisualAnchor *LogEntry
+
+	visualBase map[*LogEntry]bool
+	// viewTexts are copied for the rows of the log view, folded frames included
+	viewTexts []string
+
+	patternClusters []*Cluster
+	lastPipeCommand string
+	infoGeneration int
+	// infoFlashed is the generation of the message flashed in the Info bar
+	infoFlashed int
+	// infoStatus is shown in the Info bar above the keys, see updateInfoStatus
+	infoStatus string
+	infoMu sync.Mutex
+
+	// Actions are run on the key listener goroutine, holding tabsMu
+	Actions chan func()
+	tabsMu sync.Mutex
+
+	// Recorder is set when recording, Replay when replaying a recording
+	Recorder *Recorder
+	Replay *Replay
+
+	// params and profile the config was resolved with, used again on reload
+	params map[string]string
+	profile string
 }
 
+const infoText = "Press [?](fg:yellow) for the keys, [q](fg:yellow) to quit"
+
 var rowSeparatorStyle = ui.NewStyle(ui.Color(240))
 var selectedRowStyleInactive = ui.NewStyle(ui.ColorWhite, ui.Color(239))
 var selectedRowStyleActive = ui.NewStyle(ui.ColorWhite, ui.Color(240))
@@ -61,12 +125,12 @@ var defaultLogConfig = Config {
 		{
 			Title: "System log",
 			Command: "tail -1000f /var/log/syslog",
-			EntryPattern: "\\w{3} \\d{1,2} \\d{2}:\\d{2}:\\d{2}",
+			EntryPattern: autoEntryPattern,
 		},
 		{
 			Title: "Kernel log",
 			Command: "tail -1000f /var/log/kern.log",
-			EntryPattern: "\\w{3} \\d{1,2} \\d{2}:\\d{2}:\\d{2}",			
+			EntryPattern: autoEntryPattern,
 		},
 	},
 }
@@ -77,39 +141,66 @@ func main() {
 	viper.AddConfigPath(".")
 	viper.AddConfigPath("$HOME")
 
-	noConfig := false
-
-	config := &Config{}
-
-	args := os.Args[1:]
-	argsMap := map[string]string{}
-	for i := 0; i < len(args); i++ {
-		if (args[i] == "-c") {
-			// configPath = args[i+1]
-			viper.SetConfigFile(args[i+1])
-			i++
-		} else if (args[i] == "-l") {
-			noConfig = true
-			config.Logs = append(config.Logs, LogConfig{Command: args[i+1], Title: "Log"})
-			i++
-		} else if (strings.Index(args[i], "--") == 0) {
-			argsMap[args[i][2:]] = args[i+1]
-			i++
+	opts, err := parseArgs(os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "go-log-reader: %v\nRun `go-log-reader --help` for usage.\n", err)
+		os.Exit(2)
+	}
+	if opts.Help {
+		fmt.Print(usage)
+		return
+	}
+	if opts.Version {
+		fmt.Println(versionString())
+		return
+	}
+	if opts.ConfigFile != "" {
+		viper.SetConfigFile(opts.ConfigFile)
+	}
+
+	switch opts.Command {
+	case "check":
+		os.Exit(runCheck(opts))
+	case "cat":
+		os.Exit(runCat(opts, os.Stdout, os.Stderr))
+	}
+
+	var recorder *Recorder
+	var replay *Replay
+	if opts.Command == "record" || opts.Command == "replay" {
+		if len(opts.Args) != 1 {
+			fmt.Fprintf(os.Stderr, "go-log-reader: %s needs a file\n", opts.Command)
+			os.Exit(2)
+		}
+	}
+	if opts.Command == "record" {
+		if recorder, err = newRecorder(opts.Args[0]); err != nil {
+			fmt.Fprintf(os.Stderr, "go-log-reader: %v\n", err)
+			os.Exit(1)
+		}
+		defer recorder.Close()
+	}
+	if opts.Command == "replay" {
+		if replay, err = openReplay(opts.Args[0], opts.Speed); err != nil {
+			fmt.Fprintf(os.Stderr, "go-log-reader: %v\n", err)
+			os.Exit(1)
 		}
 	}
 
-	if !noConfig {
-		if err := viper.ReadInConfig(); err != nil {
-			// if _, ok := err.(viper.ConfigFileNotFoundError); ok {
-			// 	log.Fatalf("%v", err)
-			// } else {
-			// 	log.Fatalf("%v", err)
-			// }
-			config = &defaultLogConfig
-		} else {
-			if err := viper.Unmarshal(&config); err != nil {
-				log.Fatalf("failed to parse config: %v", err)
-			}
+	argsMap := opts.Params
+	config := &Config{Logs: opts.Logs}
+	problems := []ConfigProblem{}
+
+	if len(opts.Logs) == 0 {
+		config, problems = readConfig()
+		if config != nil && replay != nil {
+			// the recorded logs are replayed instead of the logs of the config, the rest of it still applies
+			replayConfig := *config
+			replayConfig.Logs, replayConfig.Profiles, replayConfig.Params = nil, nil, nil
+			config = &replayConfig
+		}
+		if config != nil {
+			problems = append(problems, validateProfiles(config)...)
 		}
 	}
 
@@ -120,21 +211,78 @@ func main() {
 
 	tb.SetInputMode(tb.InputEsc)
 
-	for key, val := range argsMap {
-		for j := 0; j < len(config.Logs); j++ {
-			config.Logs[j].Command = strings.Replace(config.Logs[j].Command, fmt.Sprintf("${%s}", key), val, -1)
+	uiEvents := ui.PollEvents()
+
+	profileName := ""
+	if len(problems) == 0 {
+		profile, ok, err := selectProfile(uiEvents, config, opts.Profile)
+		if err != nil {
+			problems = append(problems, ConfigProblem{File: viper.ConfigFileUsed(), Message: err.Error()})
+		} else if !ok {
+			return
+		} else if profile != nil {
+			profileName = profile.Name
+			applyProfile(config, profile, argsMap)
 		}
 	}
 
-	termWidth, termHeight := ui.TerminalDimensions()
+	if len(problems) == 0 {
+		params, ok := askParams(uiEvents, config, argsMap)
+		if !ok {
+			return
+		}
+		argsMap = params
+		problems = validateConfig(config, argsMap)
+		if replay == nil && slices.ContainsFunc(config.Logs, func(logConfig LogConfig) bool { return logConfig.Stdin }) && stdinIsTerminal() {
+			problems = append(problems, ConfigProblem{Message: "nothing is piped to stdin, run it as `<command> | go-log-reader -`"})
+		}
+	}
 
-	tabNames := []string{}
+	if len(problems) > 0 {
+		showProblems(uiEvents, problems)
+		ui.Close()
+		os.Exit(1)
+	}
 
-	for _,logConfig := range config.Logs {
-		tabNames = append(tabNames, logConfig.Title)
+	applyParams(config, argsMap)
+
+	ctx := newContext(config)
+	ctx.Recorder = recorder
+	ctx.Replay = replay
+	ctx.params = argsMap
+	ctx.profile = profileName
+
+	// the listeners of the first tabs may read lines while the next ones are added
+	ctx.tabsMu.Lock()
+	if replay != nil {
+		replay.start(ctx)
+	} else {
+		for _, logConfig := range config.Logs {
+			openTab(ctx, logConfig, true)
+		}
 	}
 
-	tabpane := widgets.NewTabPane(tabNames...)
+	updateGridLayout(ctx)
+	render(ctx.Tabs, ctx.Grid, ctx.Info)
+	ctx.tabsMu.Unlock()
+
+	if len(opts.Logs) == 0 && replay == nil && viper.ConfigFileUsed() != "" {
+		watchConfig(ctx)
+	}
+
+	quit := make(chan bool, 1)
+
+	go listenKeys(ctx, uiEvents, quit)
+	go refreshRates(ctx)
+
+	<-quit
+}
+
+// newContext creates the widgets for the config, without any tabs.
+func newContext(config *Config) *Context {
+	termWidth, termHeight := terminalDimensions()
+
+	tabpane := widgets.NewTabPane()
 	tabpane.SetRect(0, 1, termWidth, 2)
 	tabpane.Border = false
 	tabpane.ActiveTabStyle.Fg = ui.ColorCyan
@@ -144,180 +292,278 @@ func main() {
 	logView.PaddingLeft = 1
 	logView.Title = " Log Entry "
 
-	info := widgets.NewParagraph()
+	patternView := customWidgets.NewList()
+	patternView.PaddingLeft = 1
+	patternView.Title = " Patterns "
+	patternView.SelectedRowStyle = selectedRowStyleActive
+
+	outputView := customWidgets.NewList()
+	outputView.WrapText = true
+	outputView.PaddingLeft = 1
+	outputView.SelectedRowStyle = selectedRowStyleActive
+
+	info := customWidgets.NewParagraph()
 	info.PaddingLeft = 1
 	info.PaddingRight = 1
 	info.SetRect(0, termHeight - 4, termWidth, termHeight)
 	info.Title = " Info "
-	info.Text = "Press [l](fg:yellow) to show/hide log list"
+	info.Text = infoText
+
+	entryRate := customWidgets.NewSparkline()
+	entryRate.LineColor = ui.ColorCyan
+	errorRate := customWidgets.NewSparkline()
+	errorRate.LineColor = ui.ColorRed
+	rateView := customWidgets.NewSparklineGroup(entryRate, errorRate)
 
 	grid := ui.NewGrid()
 	grid.SetRect(0, 2, termWidth, termHeight - 4)
 
-	lt := ui.NewBlock()
-
-	grid.Set(
-		ui.NewRow(1.0,
-			ui.NewCol(1.0/3, lt),
-			ui.NewCol(2.0/3, logView),
-		),
-	)
-
-	ui.Render(tabpane, grid, info)
-
-	logTables := []*customWidgets.RawTable{}
-
-	for range config.Logs {
-		logTable := customWidgets.NewRawTable()
-		logTable.PaddingRight = 1
-		logTable.Border = false
-		logTable.FillRow = true
-		logTable.SeparatorStyle = rowSeparatorStyle
-		logTable.SetRect(lt.Rectangle.Min.X, lt.Rectangle.Min.Y, lt.Rectangle.Max.X, lt.Rectangle.Max.Y)
-
-		logTable.ColumnWidths = []int{termWidth / 2}
-		logTables = append(logTables, logTable)
-	}
-
-	ui.Render(logTables[0])
-
 	ctx := &Context{
 		ActivePane: ActiveLeft,
 		ActiveRow: -1,
-		Config: config,
+		Config: &Config{},
 		Tabs: tabpane,
-		LogTables: logTables,
 		LogView: logView,
+		PatternView: patternView,
+		OutputView: outputView,
 		Info: info,
+		RateView: rateView,
 		Grid: grid,
 		LeftHidden: false,
 		RightHidden: false,
+		Alerts: newAlerts(config.Alerts),
+		Actions: make(chan func()),
 	}
+	*ctx.Config = *config
+	ctx.Config.Logs = nil
+	return ctx
+}
 
-	for i := range config.Logs {
-		go listenLog(ctx, i)
-	}
+// showProblems shows config problems until a key is pressed.
+func showProblems(uiEvents <-chan ui.Event, problems []ConfigProblem) {
+	termWidth, termHeight := ui.TerminalDimensions()
 
-	quit := make(chan bool, 1)
+	lines := []string{}
+	for _, problem := range problems {
+		lines = append(lines, problem.String())
+	}
 
-	go listenKeys(ctx, quit)
+	paragraph := customWidgets.NewRawParagraph()
+	paragraph.Title = " Configuration errors "
+	paragraph.BorderStyle = alertInfoStyle
+	paragraph.PaddingLeft = 1
+	paragraph.PaddingRight = 1
+	paragraph.Text = strings.Join(lines, "\n") + "\n\n\x1b[33mRun `go-log-reader check` to see this list. Press any key to quit.\x1b[39m"
+	paragraph.SetRect(0, 0, termWidth, termHeight)
+	ui.Render(paragraph)
+
+	for e := range uiEvents {
+		if e.Type == ui.KeyboardEvent {
+			return
+		}
+	}
+}
 
-	<-quit
+// listenKeys handles keys and actions one at a time. It holds tabsMu meanwhile, so log listeners
+// and timers never change the tabs under a key.
+func listenKeys(ctx *Context, uiEvents <-chan ui.Event, quit chan bool) {
+	for {
+		select {
+		case e := <-uiEvents:
+			ctx.tabsMu.Lock()
+			handleKey(ctx, e, quit)
+			ctx.tabsMu.Unlock()
+		case action := <-ctx.Actions:
+			ctx.tabsMu.Lock()
+			action()
+			ctx.tabsMu.Unlock()
+		}
+	}
 }
 
-func listenKeys(ctx *Context, quit chan bool) {
-	uiEvents := ui.PollEvents()
+// handleKey handles a key, holding tabsMu.
+func handleKey(ctx *Context, e ui.Event, quit chan bool) {
+	// ctx.Info.Text = e.ID
 	logTable := ctx.LogTables[ctx.Tabs.ActiveTabIndex]
 
-	for {
-		e := <-uiEvents
-		// ctx.Info.Text = e.ID
-		
-		switch e.ID {
-		case "q":
-			quit <- true
-
-		case "<C-c>":
-			data  := ""
-			if ctx.ActivePane == ActiveLeft {
-				if len(logTable.Rows) > 0 {
-					data = logTable.Rows[ctx.ActiveRow][0]
-				}
-			} else {
-				if len(ctx.LogView.Rows) > 0 {
-					data = ctx.LogView.Rows[ctx.LogView.SelectedRow]
-				}
-			}
-			if len(data) > 0 {
-				clipboard.WriteAll(customWidgets.StripAsciiCodes(data))
-			}
+	if ctx.Prompt != nil {
+		handlePromptKey(ctx, e.ID)
+		return
+	}
 
-		case "l":
-			ctx.LeftHidden = !ctx.LeftHidden
-			updateGridLayout(ctx)
-			ui.Render(ctx.Grid, logTable, ctx.LogView)
-
-		case "<Left>":
-				ctx.Tabs.ActiveTabIndex = (ctx.Tabs.ActiveTabIndex + len(ctx.Tabs.TabNames) - 1) % len(ctx.Tabs.TabNames)
-				logTable = ctx.LogTables[ctx.Tabs.ActiveTabIndex]
-				ctx.ActiveRow = -1
-				logTable.ActiveRowIndex = ctx.ActiveRow
-				setViewText(ctx)
-				ui.Render(logTable, ctx.LogView, ctx.Tabs)
-
-		case "<Right>":
-				ctx.Tabs.ActiveTabIndex = (ctx.Tabs.ActiveTabIndex + 1) % len(ctx.Tabs.TabNames)
-				logTable = ctx.LogTables[ctx.Tabs.ActiveTabIndex]
-				ctx.ActiveRow = -1
-				logTable.ActiveRowIndex = ctx.ActiveRow
-				setViewText(ctx)
-				ui.Render(logTable, ctx.LogView, ctx.Tabs)
-
-		case "<Down>":
-			if ctx.ActivePane == ActiveRight {
-				ctx.LogView.ScrollDown()
-			} else {
-				if ctx.ActiveRow < len(logTable.Rows) - 1 {
-					ctx.ActiveRow += 1
-					updateSelectedRowStyle(ctx)
-					logTable.ActiveRowIndex = ctx.ActiveRow
-					setViewText(ctx)
-				}
-			}
-			ui.Render(logTable, ctx.LogView)
-
-		case "<Up>":
-			if ctx.ActivePane == ActiveRight {
-				ctx.LogView.ScrollUp()
-			} else {
-				if ctx.ActiveRow > 0 {
-					ctx.ActiveRow -= 1
-					updateSelectedRowStyle(ctx)
-					logTable.ActiveRowIndex = ctx.ActiveRow
-					setViewText(ctx)
-				}
-			}
-			ui.Render(logTable, ctx.LogView)
+	if ctx.OutputShown && handleOutputKey(ctx, e.ID) {
+		return
+	}
 
-		case "<Escape>":
-			if ctx.ActiveRow > -1 {
-				ctx.ActiveRow = -1
-				logTable.ActiveRowIndex = ctx.ActiveRow
-				setViewText(ctx)
-			}
-			ui.Render(logTable, ctx.LogView)
+	if ctx.PatternsShown && handlePatternKey(ctx, e.ID) {
+		return
+	}
+
+	if ctx.ActivePane == ActiveLeft && handleMarkKey(ctx, e.ID) {
+		return
+	}
 
-		case "<Tab>":
-			ctx.ActivePane = (ctx.ActivePane + 1) % 2
+	switch e.ID {
+	case "q":
+		quit <- true
 
-			if (ctx.ActivePane == ActiveLeft) {
-				logTable.TitleStyle.Modifier = ui.ModifierBold
-				logTable.BorderStyle.Modifier = ui.ModifierBold
-			} else {
-				logTable.TitleStyle.Modifier = ui.ModifierClear
-				logTable.BorderStyle.Modifier = ui.ModifierClear
+	case "<C-c>":
+		data  := ""
+		if ctx.ActivePane == ActiveLeft {
+			data = entriesText(selectedEntries(ctx))
+		} else {
+			if ctx.LogView.SelectedRow < len(ctx.viewTexts) {
+				data = ctx.viewTexts[ctx.LogView.SelectedRow]
 			}
+		}
+		if len(data) > 0 {
+			copyToClipboard(ctx, customWidgets.StripAsciiCodes(data))
+		}
+
+	case "l":
+		ctx.LeftHidden = !ctx.LeftHidden
+		updateGridLayout(ctx)
+		render(ctx.Grid, logTable, rightPane(ctx))
+
+	case "p":
+		togglePatterns(ctx)
+
+	case "?":
+		showHelp(ctx)
+
+	case "x":
+		startExport(ctx, false)
+
+	case "X":
+		startExport(ctx, true)
+
+	case "r":
+		toggleRates(ctx)
+
+	case "|":
+		promptPipe(ctx)
+
+	case "o":
+		openExternal(ctx, "EDITOR", "vi")
+
+	case "O":
+		openExternal(ctx, "PAGER", "less")
+
+	case "t":
+		promptOpenTab(ctx)
+
+	case "w":
+		closeActiveTab(ctx)
+
+	case "d":
+		promptDuplicateTab(ctx)
 
-			if (ctx.ActivePane == ActiveRight) {
-				ctx.LogView.SelectedRowStyle = selectedRowStyleActive
-				ctx.LogView.TitleStyle.Modifier = ui.ModifierBold
-				ctx.LogView.BorderStyle.Modifier = ui.ModifierBold
-			} else {
-				ctx.LogView.SelectedRowStyle = ctx.LogView.TextStyle
-				ctx.LogView.TitleStyle.Modifier = ui.ModifierClear
-				ctx.LogView.BorderStyle.Modifier = ui.ModifierClear
+	case "n":
+		promptRenameTab(ctx)
+
+	case "W":
+		promptSaveTabs(ctx)
+
+	case "P":
+		if ctx.Replay != nil {
+			toggleReplayPause(ctx)
+		}
+
+	case ">":
+		if ctx.Replay != nil {
+			nextReplaySpeed(ctx)
+		}
+
+	case "e":
+		ctx.Expanded = !ctx.Expanded
+		setViewText(ctx)
+		render(rightPane(ctx))
+
+	case "s":
+		toggleStackTraces(ctx)
+
+	case "f":
+		togglePayloads(ctx)
+
+	case "z":
+		toggleWrap(ctx)
+
+	case "<Left>":
+		if scrollsColumns(ctx) {
+			scrollColumns(ctx, -columnScrollStep)
+		} else {
+			switchTab(ctx, (ctx.Tabs.ActiveTabIndex + len(ctx.Tabs.TabNames) - 1) % len(ctx.Tabs.TabNames))
+		}
+
+	case "<Right>":
+		if scrollsColumns(ctx) {
+			scrollColumns(ctx, columnScrollStep)
+		} else {
+			switchTab(ctx, (ctx.Tabs.ActiveTabIndex + 1) % len(ctx.Tabs.TabNames))
+		}
+
+	case "<Down>":
+		if ctx.ActivePane == ActiveRight {
+			ctx.LogView.ScrollDown()
+		} else {
+			moveActiveRow(ctx, 1)
+		}
+		render(logTable, rightPane(ctx))
+
+	case "<Up>":
+		if ctx.ActivePane == ActiveRight {
+			ctx.LogView.ScrollUp()
+		} else {
+			if ctx.ActiveRow > 0 {
+				moveActiveRow(ctx, -1)
 			}
-			updateSelectedRowStyle(ctx)
-			ui.Render(logTable, ctx.LogView, ctx.Tabs, ctx.Info)
-
-		case "<Resize>":
-			termWidth, termHeight := ui.TerminalDimensions()
-			ctx.Tabs.SetRect(0, 1, termWidth, 2)
-			ctx.Info.SetRect(0, termHeight - 4, termWidth, termHeight)
-			ctx.Grid.SetRect(0, 2, termWidth, termHeight - 4)
-			updateGridLayout(ctx)
-			ui.Render(ctx.Grid, logTable, ctx.LogView, ctx.Tabs, ctx.Info)
 		}
+		render(logTable, rightPane(ctx))
+
+	case "<Escape>":
+		if ctx.VisualAnchor != nil {
+			toggleVisual(ctx)
+		} else if ctx.ActiveRow > -1 {
+			ctx.ActiveRow = -1
+			logTable.ActiveRowIndex = ctx.ActiveRow
+			setViewText(ctx)
+		}
+		render(logTable, rightPane(ctx))
+
+	case "<Tab>":
+		ctx.ActivePane = (ctx.ActivePane + 1) % 2
+
+		if (ctx.ActivePane == ActiveLeft) {
+			logTable.TitleStyle.Modifier = ui.ModifierBold
+			logTable.BorderStyle.Modifier = ui.ModifierBold
+		} else {
+			logTable.TitleStyle.Modifier = ui.ModifierClear
+			logTable.BorderStyle.Modifier = ui.ModifierClear
+		}
+
+		if (ctx.ActivePane == ActiveRight) {
+			ctx.LogView.SelectedRowStyle = selectedRowStyleActive
+			ctx.LogView.TitleStyle.Modifier = ui.ModifierBold
+			ctx.LogView.BorderStyle.Modifier = ui.ModifierBold
+		} else {
+			ctx.LogView.SelectedRowStyle = ctx.LogView.TextStyle
+			ctx.LogView.TitleStyle.Modifier = ui.ModifierClear
+			ctx.LogView.BorderStyle.Modifier = ui.ModifierClear
+		}
+		updateSelectedRowStyle(ctx)
+		render(logTable, rightPane(ctx), ctx.Tabs, ctx.Info)
+
+	case "<Resize>":
+		termWidth, termHeight := terminalDimensions()
+		ctx.Tabs.SetRect(0, 1, termWidth, 2)
+		ctx.Grid.SetRect(0, 2, termWidth, termHeight - 4)
+		updateGridLayout(ctx)
+		updateBottomLayout(ctx)
+		render(ctx.Grid, logTable, rightPane(ctx), ctx.Tabs)
+		renderBottom(ctx)
+
+	default:
+		runPipeKey(ctx, e.ID)
 	}
 }
 
@@ -335,96 +581,238 @@ func updateSelectedRowStyle(ctx *Context) {
 
 func updateGridLayout(ctx *Context) {
 	logTable := ctx.LogTables[ctx.Tabs.ActiveTabIndex]
+	// Set adds to the items of the grid
+	ctx.Grid.Items = nil
 	if (ctx.LeftHidden) {
 		ctx.Grid.Set(
 			ui.NewRow(1.0,
-				ctx.LogView,
+				rightPane(ctx),
 			),
 		)
 		ctx.LogView.Border = false
-		ctx.LogView.Title = ""
 	} else {
 		ctx.Grid.Set(
 			ui.NewRow(1.0,
 				ui.NewCol(1.0/3, logTable),
-				ui.NewCol(2.0/3, ctx.LogView),
+				ui.NewCol(2.0/3, rightPane(ctx)),
 			),
 		)
 		ctx.LogView.Border = true
-		ctx.LogView.Title = " Log View "
 	}
+	updateLogViewTitle(ctx)
 }
 
-func setViewText(ctx *Context) {
-	logTable := ctx.LogTables[ctx.Tabs.ActiveTabIndex]
-	row := ctx.ActiveRow
-	if row == -1 {
-		row = 0
+// renderMu serializes drawing, nothing is drawn while termui is suspended
+var renderMu sync.Mutex
+var suspended bool
+
+// drawItems, clearScreen and terminalDimensions are replaced by tests, to render without a terminal
+var drawItems = ui.Render
+var clearScreen = ui.Clear
+var terminalDimensions = ui.TerminalDimensions
+
+func render(items ...ui.Drawable) {
+	renderMu.Lock()
+	defer renderMu.Unlock()
+	if !suspended {
+		drawItems(items...)
 	}
+}
 
-	if (len(logTable.Rows) > row) {
-		ctx.LogView.Rows = strings.Split(logTable.Rows[row][0], "\n")
+// flashInfo shows a message in the Info bar for a few seconds, then restores the default text.
+func flashInfo(ctx *Context, text string, style ui.Style) {
+	flashInfoFor(ctx, text, style, time.Second * 3)
+}
+
+func flashInfoFor(ctx *Context, text string, style ui.Style, duration time.Duration) {
+	ctx.infoMu.Lock()
+	ctx.infoGeneration++
+	generation := ctx.infoGeneration
+	ctx.infoFlashed = generation
+	ctx.Info.Text = text
+	ctx.Info.BorderStyle = style
+	ctx.infoMu.Unlock()
+	render(ctx.Info)
+
+	time.AfterFunc(duration, func() {
+		ctx.infoMu.Lock()
+		current := ctx.infoGeneration == generation
+		ctx.infoMu.Unlock()
+		if current {
+			renderInfo(ctx)
+		}
+	})
+}
+
+func setViewText(ctx *Context) {
+	if entry := activeEntry(ctx); entry != nil {
+		rows := entryViewRows(entry, ctx.Expanded)
+		if !ctx.RawPayloads {
+			rows = entryPayloads(entry, rows)
+		}
+		ctx.LogView.Rows, ctx.viewTexts = foldStackTraces(rows, ctx.FramesExpanded, ctx.Config.StackTraces.LibraryPrefixes)
 	} else {
 		ctx.LogView.Rows = []string{}
+		ctx.viewTexts = nil
 	}
 	ctx.LogView.SelectedRow = 0
+	if !ctx.LogView.WrapText {
+		// the column stays when the view changes, as long as the rows are that wide
+		ctx.LogView.ScrollColumns(0)
+		updateLogViewTitle(ctx)
+	}
 }
 
-func listenLog(ctx *Context, index int) {
-	cmdArr := strings.Split(ctx.Config.Logs[index].Command, " ")
-  cmd := exec.Command(cmdArr[0], cmdArr[1:]...)
-
-	logStartRe, _ := regexp.Compile(ctx.Config.Logs[index].EntryPattern)
-
-  logTable := ctx.LogTables[index]
-
-  stdout, _ := cmd.StdoutPipe()
-  cmd.Start()
-
-  init := true
-  timer := time.NewTimer(time.Millisecond * 100)
-  go func() {
-  	<- timer.C
-		init = false
-		ui.Render(logTable, ctx.LogView)
-  }()
-
-  scanner := bufio.NewScanner(stdout)
-
-  for scanner.Scan() {
-    str := scanner.Text()
-    if logStartRe.MatchString(str) {
-  		logTable.Rows = append([][]string{{strings.TrimSpace(str)}}, logTable.Rows...)
-  		if ctx.ActiveRow > -1 {
-  			ctx.ActiveRow += 1
-				updateSelectedRowStyle(ctx)
-				logTable.ActiveRowIndex = ctx.ActiveRow
-				if (ctx.Tabs.ActiveTabIndex == index) {
-					if !init {
-						ui.Render(logTable)
-					}
-				}
-  		}
-    } else {
-    	if len(logTable.Rows) > 0 {
-    		logTable.Rows[0][0] += "\n" + str
-    	}
-    }
-
-		if (ctx.Tabs.ActiveTabIndex == index) {
-	    if len(logTable.Rows) > 0 {
-	    	row := 0
-	    	if ctx.ActiveRow > -1 && ctx.ActiveRow < len(logTable.Rows) {
-	    		row = ctx.ActiveRow
-	    	}
-	    	ctx.LogView.Rows = strings.Split(logTable.Rows[row][0], "\n")
-	    	ctx.LogView.SelectedRow = 0
-	    }
-
-	    if !init {
-				ui.Render(logTable, ctx.LogView)
-			}
-		}	
-  }
-  cmd.Wait()
-}
\ No newline at end of file
+// listenLog reads the log of the tab with the state, until the tab is closed or restarted.
+func listenLog(ctx *Context, state *LogState) {
+	ctx.tabsMu.Lock()
+	index := tabIndex(ctx, state)
+	if index == -1 {
+		// closed before the listener started
+		ctx.tabsMu.Unlock()
+		return
+	}
+	generation := state.generation
+	state.Ended = false
+	logConfig := ctx.Config.Logs[index]
+	input, cmd, err := openSource(logConfig, nil)
+	state.cmd = cmd
+	ctx.Recorder.source(state, logConfig)
+	ctx.tabsMu.Unlock()
+
+	if err != nil {
+		flashInfo(ctx, fmt.Sprintf("Failed to start log: %v", err), alertInfoStyle)
+	}
+
+	// the first lines are drawn at once, unless the log ends before
+	var drawing atomic.Bool
+	timer := time.NewTimer(time.Millisecond * 100)
+	stopped := make(chan bool)
+	initDone := make(chan bool)
+	go func() {
+		defer close(initDone)
+		select {
+		case <- timer.C:
+		case <- stopped:
+			return
+		}
+		ctx.tabsMu.Lock()
+		defer ctx.tabsMu.Unlock()
+		drawing.Store(true)
+		if index := tabIndex(ctx, state); index == ctx.Tabs.ActiveTabIndex {
+			render(ctx.LogTables[index], rightPane(ctx))
+		}
+	}()
+
+	scanner := bufio.NewScanner(input)
+
+	for scanner.Scan() {
+		str := scanner.Text()
+		now, draw := time.Now(), drawing.Load()
+		if reader, ok := input.(*lineReader); ok {
+			var drawn bool
+			now, drawn = reader.lineTime()
+			draw = draw && drawn
+		}
+		if !ingestLine(ctx, state, generation, str, now, draw) {
+			break
+		}
+		ctx.Recorder.line(state, now, str)
+	}
+	close(stopped)
+	<- initDone
+
+	if cmd != nil {
+		cmd.Wait()
+	}
+	endLog(ctx, state, generation)
+}
+
+// ingestLine adds a line of the log to its tab and its duplicates, returns false if the tab was closed or restarted.
+func ingestLine(ctx *Context, state *LogState, generation int, str string, now time.Time, draw bool) bool {
+	ctx.tabsMu.Lock()
+	defer ctx.tabsMu.Unlock()
+
+	index := tabIndex(ctx, state)
+	if index == -1 || state.generation != generation {
+		return false
+	}
+
+	for i, other := range ctx.LogStates {
+		if other == state || other.source == state {
+			addLine(ctx, i, str, now, draw)
+		}
+	}
+	return true
+}
+
+// addLine adds a line to the tab, and shows it if the tab is active.
+func addLine(ctx *Context, index int, str string, now time.Time, draw bool) {
+	if sampleLine(ctx, index, str, now) {
+		return
+	}
+	groupLine(ctx, index, str, now)
+
+	if (ctx.Tabs.ActiveTabIndex == index) {
+		setViewText(ctx)
+
+		if draw {
+			render(ctx.LogTables[index], rightPane(ctx))
+		}
+	}
+}
+
+// groupLine adds the line to the newest entry of the tab or starts a new one.
+func groupLine(ctx *Context, index int, str string, now time.Time) {
+	state := ctx.LogStates[index]
+	rules := state.rules(ctx.Config.Logs[index])
+	closed := state.entryClosed || rules.idle(state.entryLast, now)
+	if rules.startsEntry(str, state.entryLines, closed) || !appendToEntry(ctx, index, str) {
+		finishEntry(ctx, index)
+		addEntry(ctx, index, newLogEntry(strings.TrimSpace(str), now))
+		state.entryLines = 1
+		state.entryFinished = false
+	} else {
+		state.entryLines++
+	}
+	state.entryClosed = rules.ends(str)
+	state.entryLast = now
+	if rules.timeout > 0 {
+		finishWhenIdle(ctx, index, rules.timeout)
+	}
+
+	if len(state.Entries) > 0 {
+		checkAlerts(ctx, index, str, state.Entries[0].Text)
+	}
+}
+
+// endLog is called when the input of the log ended. The viewer stays open, the tab is marked as ended,
+// and so are its duplicates, also when the tab was closed.
+func endLog(ctx *Context, state *LogState, generation int) {
+	ctx.tabsMu.Lock()
+	defer ctx.tabsMu.Unlock()
+
+	if index := tabIndex(ctx, state); index != -1 && state.generation != generation {
+		// restarted, the new listener goes on
+		return
+	}
+	for i, other := range ctx.LogStates {
+		if other == state || other.source == state {
+			endTab(ctx, i)
+		}
+	}
+}
+
+// endTab completes the last entry of the tab and marks it as ended.
+func endTab(ctx *Context, index int) {
+	state := ctx.LogStates[index]
+	flushSample(ctx, index)
+	finishEntry(ctx, index)
+	state.Ended = true
+	ctx.Tabs.TabNames[index] = tabTitle(ctx, index)
+	render(ctx.Tabs)
+	if index == ctx.Tabs.ActiveTabIndex {
+		setViewText(ctx)
+		render(ctx.LogTables[index], rightPane(ctx))
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+// moveActiveRow moves the selection in the log table, returns false if it is already at the edge.
+func moveActiveRow(ctx *Context, delta int) bool {
+	logTable := ctx.LogTables[ctx.Tabs.ActiveTabIndex]
+
+	row := ctx.ActiveRow + delta
+	if row < 0 || row > len(logTable.Rows) - 1 {
+		return false
+	}
+	ctx.ActiveRow = row
+	updateSelectedRowStyle(ctx)
+	logTable.ActiveRowIndex = ctx.ActiveRow
+	updateVisual(ctx)
+	setViewText(ctx)
+	return true
+}
+
+func toggleMark(ctx *Context) {
+	if entry := activeEntry(ctx); entry != nil {
+		entry.Marked = !entry.Marked
+	}
+}
+
+// extendMark marks the selected entry and the one next to it, like shift+arrows in a list.
+func extendMark(ctx *Context, delta int) {
+	if entry := activeEntry(ctx); entry != nil {
+		entry.Marked = true
+	}
+	if moveActiveRow(ctx, delta) {
+		activeEntry(ctx).Marked = true
+	}
+}
+
+// toggleVisual starts or ends visual mode, in which every entry between
+// the row where it started and the selected row is marked.
+func toggleVisual(ctx *Context) {
+	if ctx.VisualAnchor != nil {
+		ctx.VisualAnchor = nil
+		ctx.visualBase = nil
+		return
+	}
+
+	state := ctx.LogStates[ctx.Tabs.ActiveTabIndex]
+	if len(state.Visible) == 0 {
+		return
+	}
+	if ctx.ActiveRow == -1 {
+		moveActiveRow(ctx, 1)
+	}
+
+	ctx.VisualAnchor = activeEntry(ctx)
+	ctx.visualBase = map[*LogEntry]bool{}
+	for _, entry := range state.Entries {
+		if entry.Marked {
+			ctx.visualBase[entry] = true
+		}
+	}
+	updateVisual(ctx)
+}
+
+func updateVisual(ctx *Context) {
+	if ctx.VisualAnchor == nil {
+		return
+	}
+	state := ctx.LogStates[ctx.Tabs.ActiveTabIndex]
+
+	anchor := -1
+	for i, entry := range state.Visible {
+		if entry == ctx.VisualAnchor {
+			anchor = i
+			break
+		}
+	}
+	if anchor == -1 {
+		return
+	}
+
+	from, to := anchor, ctx.ActiveRow
+	if from > to {
+		from, to = to, from
+	}
+	for i, entry := range state.Visible {
+		entry.Marked = ctx.visualBase[entry] || (i >= from && i <= to)
+	}
+}
+
+func clearMarks(ctx *Context) {
+	ctx.VisualAnchor = nil
+	ctx.visualBase = nil
+	for _, entry := range ctx.LogStates[ctx.Tabs.ActiveTabIndex].Entries {
+		entry.Marked = false
+	}
+}
+
+// markedEntries returns marked entries of the tab, newest first.
+func markedEntries(state *LogState) []*LogEntry {
+	entries := []*LogEntry{}
+	for _, entry := range state.Entries {
+		if entry.Marked {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// selectedEntries returns marked entries, or the active one if nothing is marked, newest first.
+func selectedEntries(ctx *Context) []*LogEntry {
+	entries := markedEntries(ctx.LogStates[ctx.Tabs.ActiveTabIndex])
+	if len(entries) == 0 {
+		if entry := activeEntry(ctx); entry != nil {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// entriesText joins entries, given newest first, in chronological order.
+func entriesText(entries []*LogEntry) string {
+	text := ""
+	for i := len(entries) - 1; i >= 0; i-- {
+		if text != "" {
+			text += "\n"
+		}
+		text += entries[i].Text
+	}
+	return text
+}
+
+func handleMarkKey(ctx *Context, id string) bool {
+	switch id {
+	case "<Space>":
+		toggleMark(ctx)
+	case "J":
+		extendMark(ctx, 1)
+	case "K":
+		extendMark(ctx, -1)
+	case "v":
+		toggleVisual(ctx)
+	case "u":
+		clearMarks(ctx)
+	default:
+		return false
+	}
+	render(ctx.LogTables[ctx.Tabs.ActiveTabIndex], rightPane(ctx))
+	return true
+}
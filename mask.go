@@ -0,0 +1,54 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+
+	customWidgets "replika.com/log-reader/widgets"
+)
+
+const wildcardToken = "<*>"
+
+var timestampRe = regexp.MustCompile(
+	`\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(?:[.,]\d+)?(?:Z|[+-]\d{2}:?\d{2})?` +
+		`|\b[A-Z][a-z]{2} +\d{1,2} \d{2}:\d{2}:\d{2}\b` +
+		`|\b\d{2}:\d{2}:\d{2}(?:[.,]\d+)?\b`)
+
+var numberRe = regexp.MustCompile(`\d+(?:\.\d+)?`)
+
+var quotedRe = regexp.MustCompile(`"(?:[^"\\]|\\.)*"|'(?:[^'\\]|\\.)*'`)
+
+var variableTokenRe = regexp.MustCompile(
+	`^(?:` +
+		`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}` + // uuid
+		`|\d{1,3}(?:\.\d{1,3}){3}(?::\d+)?` + // ipv4
+		`|[0-9a-fA-F:]*:[0-9a-fA-F:]+` + // ipv6
+		`|(?:0x)?[0-9a-fA-F]*\d[0-9a-fA-F]*` + // numbers and hex
+		`|[-+]?\d+(?:[.,]\d+)*(?:ms|s|m|h|kb|mb|gb|b|%)?` +
+		`)$`)
+
+// maskEntry replaces timestamps and numbers, so that entries differing only in those compare equal.
+func maskEntry(text string) string {
+	text = customWidgets.StripAsciiCodes(text)
+	text = timestampRe.ReplaceAllString(text, "<ts>")
+	return numberRe.ReplaceAllString(text, "<n>")
+}
+
+// templateTokens splits the first line of an entry into tokens, replacing variable ones
+// (timestamps, numbers, UUIDs, IPs, quoted strings) with a wildcard.
+func templateTokens(text string) []string {
+	text = firstLine(text)
+	text = timestampRe.ReplaceAllString(text, wildcardToken)
+	text = quotedRe.ReplaceAllString(text, wildcardToken)
+
+	tokens := strings.Fields(text)
+	for i, token := range tokens {
+		trimmed := strings.Trim(token, "()[]{},;")
+		if value := trimmed[strings.IndexByte(trimmed, '=')+1:]; value != trimmed && variableTokenRe.MatchString(value) {
+			tokens[i] = trimmed[:len(trimmed)-len(value)] + wildcardToken
+		} else if variableTokenRe.MatchString(trimmed) {
+			tokens[i] = wildcardToken
+		}
+	}
+	return tokens
+}
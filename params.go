@@ -0,0 +1,202 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	ui "github.com/gizak/termui/v3"
+	"github.com/spf13/viper"
+	customWidgets "replika.com/log-reader/widgets"
+)
+
+type ParamConfig struct {
+	Name    string   `mapstructure:"name"`
+	Default string   `mapstructure:"default"`
+	Choices []string `mapstructure:"choices"`
+}
+
+// configParams returns declared params followed by placeholders used in commands without a declaration.
+func configParams(config *Config) []ParamConfig {
+	params := append([]ParamConfig{}, config.Params...)
+	declared := map[string]bool{}
+	for _, param := range params {
+		declared[param.Name] = true
+	}
+	for _, logConfig := range config.Logs {
+		for _, match := range placeholderRe.FindAllStringSubmatch(logConfig.Command, -1) {
+			if !declared[match[1]] {
+				declared[match[1]] = true
+				params = append(params, ParamConfig{Name: match[1]})
+			}
+		}
+	}
+	return params
+}
+
+func applyParams(config *Config, params map[string]string) {
+	for key, val := range params {
+		for j := 0; j < len(config.Logs); j++ {
+			config.Logs[j].Command = strings.Replace(config.Logs[j].Command, fmt.Sprintf("${%s}", key), val, -1)
+		}
+	}
+}
+
+func paramsFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "go-log-reader", "params.json")
+}
+
+// paramsKey identifies the config file the remembered params belong to.
+func paramsKey() string {
+	file := viper.ConfigFileUsed()
+	if file == "" {
+		return "default"
+	}
+	if abs, err := filepath.Abs(file); err == nil {
+		return abs
+	}
+	return file
+}
+
+// readRememberedParams returns param values saved for all config files.
+func readRememberedParams() map[string]map[string]string {
+	remembered := map[string]map[string]string{}
+	if data, err := os.ReadFile(paramsFile()); err == nil {
+		json.Unmarshal(data, &remembered)
+	}
+	return remembered
+}
+
+// rememberParams saves param values for the current config file, keeping other remembered values.
+func rememberParams(values map[string]string) error {
+	file := paramsFile()
+	if file == "" {
+		return nil
+	}
+	remembered := readRememberedParams()
+	if remembered[paramsKey()] == nil {
+		remembered[paramsKey()] = map[string]string{}
+	}
+	for key, val := range values {
+		remembered[paramsKey()][key] = val
+	}
+	return writeRemembered(file, remembered)
+}
+
+func writeRemembered(file string, remembered any) error {
+	data, err := json.MarshalIndent(remembered, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(file, data, 0644)
+}
+
+// askParams shows a form for every param without a value given on the command line,
+// prefilled with the value used last time or the default. Returns false if cancelled.
+func askParams(events <-chan ui.Event, config *Config, values map[string]string) (map[string]string, bool) {
+	result := map[string]string{}
+	for key, val := range values {
+		result[key] = val
+	}
+
+	missing := []ParamConfig{}
+	for _, param := range configParams(config) {
+		if _, ok := result[param.Name]; !ok {
+			missing = append(missing, param)
+		}
+	}
+	if len(missing) == 0 {
+		return result, true
+	}
+
+	remembered := readRememberedParams()[paramsKey()]
+	asked := map[string]string{}
+
+	for i, param := range missing {
+		value, ok := remembered[param.Name]
+		if !ok {
+			value = param.Default
+		}
+		value, ok = askParam(events, param, value, fmt.Sprintf("%d/%d", i+1, len(missing)))
+		if !ok {
+			return nil, false
+		}
+		result[param.Name] = value
+		asked[param.Name] = value
+	}
+
+	rememberParams(asked)
+	clearScreen()
+	return result, true
+}
+
+func askParam(events <-chan ui.Event, param ParamConfig, value string, progress string) (string, bool) {
+	termWidth, _ := terminalDimensions()
+
+	form := customWidgets.NewList()
+	form.Title = fmt.Sprintf(" %s ", param.Name)
+	if progress != "" {
+		form.Title = fmt.Sprintf(" %s (%s) ", param.Name, progress)
+	}
+	form.TitleStyle = promptStyle
+	form.PaddingLeft = 1
+	form.SelectedRowStyle = selectedRowStyleActive
+
+	help := customWidgets.NewRawParagraph()
+	help.Border = false
+	help.PaddingLeft = 1
+	help.Text = "\x1b[33mEnter\x1b[39m to confirm, \x1b[33mEscape\x1b[39m to quit"
+
+	height := 3
+	if len(param.Choices) > 0 {
+		form.Rows = param.Choices
+		for i, choice := range param.Choices {
+			if choice == value {
+				form.SelectedRow = i
+			}
+		}
+		height = len(param.Choices) + 2
+	}
+	form.SetRect(0, 0, termWidth, height)
+	help.SetRect(0, height, termWidth, height+1)
+
+	for {
+		if len(param.Choices) == 0 {
+			form.Rows = []string{value + "█"}
+		}
+		clearScreen()
+		render(form, help)
+
+		e := <-events
+		switch e.ID {
+		case "<Escape>", "<C-c>":
+			return "", false
+
+		case "<Enter>":
+			if len(param.Choices) > 0 {
+				return param.Choices[form.SelectedRow], true
+			}
+			return value, true
+
+		case "<Up>":
+			form.ScrollUp()
+
+		case "<Down>":
+			form.ScrollDown()
+
+		default:
+			if len(param.Choices) == 0 {
+				value = editText(value, e.ID)
+			}
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+
+	ui "github.com/gizak/termui/v3"
+)
+
+// rightPane returns the widget currently shown next to the log table.
+func rightPane(ctx *Context) ui.Drawable {
+	if ctx.OutputShown {
+		return ctx.OutputView
+	}
+	if ctx.PatternsShown {
+		updatePatternView(ctx)
+		return ctx.PatternView
+	}
+	return ctx.LogView
+}
+
+func updatePatternView(ctx *Context) {
+	state := ctx.LogStates[ctx.Tabs.ActiveTabIndex]
+
+	var selected *Cluster
+	if row := ctx.PatternView.SelectedRow - 1; row >= 0 && row < len(ctx.patternClusters) {
+		selected = ctx.patternClusters[row]
+	}
+
+	ctx.patternClusters = state.Clusters.Sorted()
+	rows := []string{"\x1b[33mAll entries\x1b[39m"}
+	for i, cluster := range ctx.patternClusters {
+		rows = append(rows, fmt.Sprintf("\x1b[33m%6d\x1b[39m %s  %s", cluster.Count, cluster.LastSeen.Format(timeFormat), cluster.Template()))
+		if cluster == selected {
+			ctx.PatternView.SelectedRow = i + 1
+		}
+	}
+	ctx.PatternView.Rows = rows
+	if ctx.PatternView.SelectedRow >= len(rows) {
+		ctx.PatternView.SelectedRow = len(rows) - 1
+	}
+}
+
+func togglePatterns(ctx *Context) {
+	ctx.PatternsShown = !ctx.PatternsShown
+	ctx.PatternView.SelectedRow = 0
+	updateGridLayout(ctx)
+	render(ctx.Grid, ctx.LogTables[ctx.Tabs.ActiveTabIndex], rightPane(ctx))
+}
+
+// handlePatternKey handles keys while the pattern view is shown, returns false for keys it doesn't use.
+func handlePatternKey(ctx *Context, id string) bool {
+	index := ctx.Tabs.ActiveTabIndex
+
+	switch id {
+	case "<Up>":
+		ctx.PatternView.ScrollUp()
+		render(ctx.PatternView)
+
+	case "<Down>":
+		ctx.PatternView.ScrollDown()
+		render(ctx.PatternView)
+
+	case "<Enter>":
+		if row := ctx.PatternView.SelectedRow - 1; row >= 0 && row < len(ctx.patternClusters) {
+			cluster := ctx.patternClusters[row]
+			setFilter(ctx, index, func(entry *LogEntry) bool {
+				return entry.Cluster == cluster
+			})
+		} else {
+			setFilter(ctx, index, nil)
+		}
+		setViewText(ctx)
+		togglePatterns(ctx)
+		render(ctx.Tabs)
+
+	case "p", "<Escape>":
+		togglePatterns(ctx)
+
+	default:
+		return false
+	}
+	return true
+}
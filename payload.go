@@ -0,0 +1,274 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+
+	ui "github.com/gizak/termui/v3"
+	customWidgets "replika.com/log-reader/widgets"
+)
+
+const (
+	jsonKeyColor     = "\x1b[36m"
+	jsonStringColor  = "\x1b[32m"
+	jsonNumberColor  = "\x1b[33m"
+	jsonLiteralColor = "\x1b[35m"
+	xmlTagColor      = "\x1b[34m"
+	xmlAttrColor     = "\x1b[36m"
+	resetColor       = "\x1b[39m"
+)
+
+// payload is a JSON or XML fragment found in a line, from start to end.
+type payload struct {
+	start, end int
+	lines      []string
+}
+
+// findPayload returns the first JSON object or array of objects, or XML element with children, in the line.
+func findPayload(line string) (payload, bool) {
+	for start := 0; start < len(line); start++ {
+		// most brackets in text don't start a payload, they're skipped without parsing the rest of the line
+		next := strings.TrimLeft(line[start+1:], " ")
+		switch line[start] {
+		case '{', '[':
+			if !strings.HasPrefix(next, `"`) && !(line[start] == '[' && (strings.HasPrefix(next, "{") || strings.HasPrefix(next, "["))) {
+				continue
+			}
+			if end, ok := jsonEnd(line, start); ok {
+				return payload{start: start, end: end, lines: prettyJSON(line[start:end])}, true
+			}
+		case '<':
+			if !xmlNameStart(line[start+1:]) {
+				continue
+			}
+			if end, lines, ok := prettyXML(line[start:]); ok {
+				return payload{start: start, end: start + end, lines: lines}, true
+			}
+		}
+	}
+	return payload{}, false
+}
+
+func xmlNameStart(text string) bool {
+	return text != "" && (text[0] >= 'a' && text[0] <= 'z' || text[0] >= 'A' && text[0] <= 'Z' || text[0] == '_')
+}
+
+// jsonEnd returns the end of the JSON value starting at start, if it's an object with keys
+// or an array of objects or arrays. Smaller values are more likely to be plain text, like "[3]".
+func jsonEnd(line string, start int) (int, bool) {
+	decoder := json.NewDecoder(strings.NewReader(line[start:]))
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return 0, false
+	}
+	switch value := value.(type) {
+	case map[string]interface{}:
+		if len(value) == 0 {
+			return 0, false
+		}
+	case []interface{}:
+		if len(value) == 0 {
+			return 0, false
+		}
+		for _, item := range value {
+			switch item.(type) {
+			case map[string]interface{}, []interface{}:
+			default:
+				return 0, false
+			}
+		}
+	default:
+		return 0, false
+	}
+	return start + int(decoder.InputOffset()), true
+}
+
+// prettyJSON indents the JSON, keeping the order of keys, and colors it.
+func prettyJSON(text string) []string {
+	var buf bytes.Buffer
+	json.Indent(&buf, []byte(text), "", "  ")
+
+	lines := []string{}
+	for _, line := range strings.Split(buf.String(), "\n") {
+		lines = append(lines, colorJSONLine(line))
+	}
+	return lines
+}
+
+// colorJSONLine colors the tokens of a line of indented JSON.
+func colorJSONLine(line string) string {
+	var builder strings.Builder
+	for i := 0; i < len(line); {
+		char := line[i]
+		switch {
+		case char == '"':
+			end := i + 1
+			for end < len(line) && line[end] != '"' {
+				if line[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			end = min(end+1, len(line))
+			color := jsonStringColor
+			if strings.HasPrefix(line[end:], ":") {
+				color = jsonKeyColor
+			}
+			builder.WriteString(color + line[i:end] + resetColor)
+			i = end
+
+		case char == '-' || char >= '0' && char <= '9' || char == 't' || char == 'f' || char == 'n':
+			end := i
+			for end < len(line) && !strings.ContainsRune(" ,]}", rune(line[end])) {
+				end++
+			}
+			color := jsonNumberColor
+			if char == 't' || char == 'f' || char == 'n' {
+				color = jsonLiteralColor
+			}
+			builder.WriteString(color + line[i:end] + resetColor)
+			i = end
+
+		default:
+			builder.WriteByte(char)
+			i++
+		}
+	}
+	return builder.String()
+}
+
+// prettyXML indents the XML element at the start of the text, if it has child elements.
+// It returns the end of the element and the colored lines.
+func prettyXML(text string) (int, []string, bool) {
+	decoder := xml.NewDecoder(strings.NewReader(text))
+	lines := []string{}
+	// names are the elements open at the current token
+	names := []string{}
+	children := false
+	// open is the start tag not written yet, so an element with only text stays on one line
+	open := ""
+	flushOpen := func() {
+		if open != "" {
+			lines = append(lines, strings.Repeat("  ", len(names)-1)+open)
+			open = ""
+		}
+	}
+
+	for {
+		token, err := decoder.RawToken()
+		if err != nil {
+			return 0, nil, false
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			children = children || len(names) > 0
+			flushOpen()
+			names = append(names, xmlName(token.Name))
+			open = xmlTagColor + "<" + xmlName(token.Name) + resetColor
+			for _, attr := range token.Attr {
+				open += " " + xmlAttrColor + xmlName(attr.Name) + resetColor + "=" + jsonStringColor + `"` + attr.Value + `"` + resetColor
+			}
+			open += xmlTagColor + ">" + resetColor
+
+		case xml.EndElement:
+			// RawToken doesn't check that elements are closed in order
+			if len(names) == 0 || names[len(names)-1] != xmlName(token.Name) {
+				return 0, nil, false
+			}
+			end := xmlTagColor + "</" + xmlName(token.Name) + ">" + resetColor
+			if open != "" {
+				end = open + end
+				open = ""
+			}
+			names = names[:len(names)-1]
+			lines = append(lines, strings.Repeat("  ", len(names))+end)
+			if len(names) == 0 {
+				return int(decoder.InputOffset()), lines, children
+			}
+
+		case xml.CharData:
+			text := strings.TrimSpace(string(token))
+			if text == "" {
+				continue
+			}
+			if open != "" {
+				open += text
+				continue
+			}
+			lines = append(lines, strings.Repeat("  ", len(names))+text)
+
+		default:
+			// a fragment starts with an element, comments and the like are only kept inside it
+			if len(names) == 0 {
+				return 0, nil, false
+			}
+		}
+	}
+}
+
+func xmlName(name xml.Name) string {
+	if name.Space != "" {
+		return name.Space + ":" + name.Local
+	}
+	return name.Local
+}
+
+// prettyPayloads replaces JSON and XML fragments in the rows of the log view with indented,
+// colored lines. The text before a fragment stays on its first line, with its colors.
+func prettyPayloads(rows []string) []string {
+	result := []string{}
+	for _, row := range rows {
+		line, offsets := customWidgets.StripAsciiCodesIndex(row)
+		found := false
+		// rest is where the text after the last fragment starts in line
+		rest := 0
+		for {
+			payload, ok := findPayload(line[rest:])
+			if !ok {
+				break
+			}
+			found = true
+			prefix := row[offsets[rest]:offsets[rest+payload.start]]
+			if strings.Contains(prefix, "\x1b") {
+				prefix += "\x1b[0m"
+			}
+			result = append(result, prefix+payload.lines[0])
+			result = append(result, payload.lines[1:]...)
+			rest += payload.end
+			for rest < len(line) && line[rest] == ' ' {
+				rest++
+			}
+			if rest == len(line) {
+				break
+			}
+		}
+		if !found {
+			result = append(result, row)
+		} else if rest < len(line) {
+			result = append(result, row[offsets[rest]:])
+		}
+	}
+	return result
+}
+
+// entryPayloads returns the rows of the entry with pretty payloads, parsed again only when the rows changed.
+func entryPayloads(entry *LogEntry, rows []string) []string {
+	key := strings.Join(rows, "\n")
+	if entry.payloadRows == nil || entry.payloadKey != key {
+		entry.payloadKey, entry.payloadRows = key, prettyPayloads(rows)
+	}
+	return entry.payloadRows
+}
+
+func togglePayloads(ctx *Context) {
+	ctx.RawPayloads = !ctx.RawPayloads
+	setViewText(ctx)
+	render(rightPane(ctx))
+	if ctx.RawPayloads {
+		flashInfo(ctx, "Payloads shown raw", ui.Theme.Block.Border)
+	} else {
+		flashInfo(ctx, "Payloads pretty-printed", ui.Theme.Block.Border)
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	customWidgets "replika.com/log-reader/widgets"
+)
+
+func TestPrettyPayloads(t *testing.T) {
+	tests := []struct {
+		name string
+		rows []string
+		want []string
+	}{
+		{
+			name: "json after the message",
+			rows: []string{`sent payload={"id":7,"tags":["a"],"ok":true,"next":null} in 3ms`},
+			want: []string{
+				"sent payload={",
+				`  "id": 7,`,
+				`  "tags": [`,
+				`    "a"`,
+				"  ],",
+				`  "ok": true,`,
+				`  "next": null`,
+				"}",
+				"in 3ms",
+			},
+		},
+		{
+			name: "array of objects",
+			rows: []string{`rows [{"a":1},{"a":2}]`},
+			want: []string{"rows [", "  {", `    "a": 1`, "  },", "  {", `    "a": 2`, "  }", "]"},
+		},
+		{
+			name: "xml",
+			rows: []string{`reply <r id="1"><name>x</name><empty/></r> done`},
+			want: []string{`reply <r id="1">`, "  <name>x</name>", "  <empty></empty>", "</r>", "done"},
+		},
+		{
+			name: "plain text",
+			rows: []string{"retry [3] {none} <br> a < b", `{}`, `[1,2]`, `<a>text</a>`, `<a><b></a></b>`, `{"unclosed": 1`},
+			want: []string{"retry [3] {none} <br> a < b", `{}`, `[1,2]`, `<a>text</a>`, `<a><b></a></b>`, `{"unclosed": 1`},
+		},
+	}
+	for _, test := range tests {
+		got := prettyPayloads(test.rows)
+		for i := range got {
+			got[i] = customWidgets.StripAsciiCodes(got[i])
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestPayloadColors(t *testing.T) {
+	got := prettyPayloads([]string{`{"key":"value","n":-1.5,"b":false}`})
+	want := []string{
+		"{",
+		"  \x1b[36m\"key\"\x1b[39m: \x1b[32m\"value\"\x1b[39m,",
+		"  \x1b[36m\"n\"\x1b[39m: \x1b[33m-1.5\x1b[39m,",
+		"  \x1b[36m\"b\"\x1b[39m: \x1b[35mfalse\x1b[39m",
+		"}",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestPayloadKeepsPrefixColors(t *testing.T) {
+	got := prettyPayloads([]string{"\x1b[31mERROR\x1b[39m sent {\"a\":1} \x1b[2min 3ms\x1b[0m"})
+	want := []string{
+		"\x1b[31mERROR\x1b[39m sent \x1b[0m{",
+		"  \x1b[36m\"a\"\x1b[39m: \x1b[33m1\x1b[39m",
+		"}",
+		"\x1b[2min 3ms\x1b[0m",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestEntryPayloadsCached(t *testing.T) {
+	entry := newLogEntry(`sent {"a":1}`, harnessTime)
+	rows := []string{entry.Text}
+	first := entryPayloads(entry, rows)
+	if second := entryPayloads(entry, rows); &second[0] != &first[0] {
+		t.Errorf("the payloads were parsed again for the same rows")
+	}
+
+	rows = append(rows, `and {"b":2}`)
+	if got := entryPayloads(entry, rows); len(got) != 6 {
+		t.Errorf("got %q after a line was added", got)
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+
+	ui "github.com/gizak/termui/v3"
+	tb "github.com/nsf/termbox-go"
+	customWidgets "replika.com/log-reader/widgets"
+)
+
+type PipeConfig struct {
+	Title   string `mapstructure:"title"`
+	Key     string `mapstructure:"key"`
+	Command string `mapstructure:"command"`
+}
+
+// builtinKeys are the keys the reader uses itself, also in the output and pattern views,
+// so pipes can't be bound to them.
+var builtinKeys = []string{
+	"q", "?", "<C-c>", "<Tab>", "<Left>", "<Right>", "<Up>", "<Down>", "<PageUp>", "<PageDown>",
+	"<Enter>", "<Escape>", "<Space>", "<Resize>", "l", "e", "s", "f", "z", "p", "r", "J", "K", "v", "u",
+	"x", "X", "|", "o", "O", "t", "w", "d", "n", "W", "P", ">",
+}
+
+// selectedText is the text of marked entries, or of the active one, without escape codes.
+func selectedText(ctx *Context) string {
+	return customWidgets.StripAsciiCodes(entriesText(selectedEntries(ctx)))
+}
+
+// runPipe pipes selected entries to a shell command and shows its output in the output view.
+func runPipe(ctx *Context, title string, command string) {
+	text := selectedText(ctx)
+	if text == "" || command == "" {
+		return
+	}
+	ctx.lastPipeCommand = command
+
+	ctx.OutputView.Title = fmt.Sprintf(" %s ", title)
+	ctx.OutputView.Rows = []string{"Running..."}
+	ctx.OutputView.SelectedRow = 0
+	showOutput(ctx)
+
+	go func() {
+		cmd := exec.Command("sh", "-c", command)
+		cmd.Stdin = strings.NewReader(text + "\n")
+		output, err := cmd.CombinedOutput()
+
+		rows := strings.Split(strings.TrimRight(string(output), "\n"), "\n")
+		if err != nil {
+			rows = append(rows, fmt.Sprintf("\x1b[31m%v\x1b[39m", err))
+		}
+		ctx.Actions <- func() {
+			ctx.OutputView.Rows = rows
+			if ctx.OutputShown {
+				render(ctx.OutputView)
+			}
+		}
+	}()
+}
+
+func promptPipe(ctx *Context) {
+	showPrompt(ctx, "Pipe to command:", ctx.lastPipeCommand, func(command string) {
+		runPipe(ctx, "| " + command, command)
+	})
+}
+
+// runPipeKey runs the pipe configured for the key, returns false if there is none.
+func runPipeKey(ctx *Context, id string) bool {
+	for _, pipe := range ctx.Config.Pipes {
+		if pipe.Key == id {
+			title := pipe.Title
+			if title == "" {
+				title = "| " + pipe.Command
+			}
+			runPipe(ctx, title, pipe.Command)
+			return true
+		}
+	}
+	return false
+}
+
+func showOutput(ctx *Context) {
+	ctx.OutputShown = true
+	updateGridLayout(ctx)
+	render(ctx.Grid, ctx.LogTables[ctx.Tabs.ActiveTabIndex], rightPane(ctx))
+}
+
+func hideOutput(ctx *Context) {
+	ctx.OutputShown = false
+	updateGridLayout(ctx)
+	render(ctx.Grid, ctx.LogTables[ctx.Tabs.ActiveTabIndex], rightPane(ctx))
+}
+
+// handleOutputKey handles keys while the output view is shown, returns false for keys it doesn't use.
+func handleOutputKey(ctx *Context, id string) bool {
+	switch id {
+	case "<Up>":
+		ctx.OutputView.ScrollUp()
+	case "<Down>":
+		ctx.OutputView.ScrollDown()
+	case "<PageUp>":
+		ctx.OutputView.ScrollPageUp()
+	case "<PageDown>":
+		ctx.OutputView.ScrollPageDown()
+	case "<Escape>", "q":
+		hideOutput(ctx)
+		return true
+	default:
+		return false
+	}
+	render(ctx.OutputView)
+	return true
+}
+
+// openExternal writes selected entries to a temporary file and opens it with the program
+// from the environment variable, suspending termui while it runs. Logs keep being read meanwhile.
+func openExternal(ctx *Context, envName string, fallback string) {
+	text := selectedText(ctx)
+	if text == "" {
+		return
+	}
+
+	program := os.Getenv(envName)
+	if program == "" {
+		program = fallback
+	}
+
+	file, err := os.CreateTemp("", "go-log-reader-*.log")
+	if err != nil {
+		flashInfo(ctx, fmt.Sprintf("Failed to create a temporary file: %v", err), alertInfoStyle)
+		return
+	}
+	defer os.Remove(file.Name())
+	file.WriteString(text + "\n")
+	file.Close()
+
+	err = suspend(ctx, func() error {
+		cmd := exec.Command("sh", "-c", program + " \"$0\"", file.Name())
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+		// stdin may be a log source, so the program gets the terminal
+		if tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0); err == nil {
+			defer tty.Close()
+			cmd.Stdin, cmd.Stdout = tty, tty
+		}
+		return cmd.Run()
+	})
+	if err != nil {
+		flashInfo(ctx, fmt.Sprintf("[%s](fg:yellow) failed: %v", program, err), alertInfoStyle)
+	}
+}
+
+// suspend closes termui, runs the function and restores the screen. It's called on the key listener
+// goroutine, which lets go of tabsMu meanwhile, so logs keep being read.
+func suspend(ctx *Context, run func() error) error {
+	renderMu.Lock()
+	suspended = true
+	ui.Close()
+	renderMu.Unlock()
+
+	ctx.tabsMu.Unlock()
+	err := run()
+	ctx.tabsMu.Lock()
+
+	renderMu.Lock()
+	if err := ui.Init(); err != nil {
+		log.Fatalf("failed to initialize termui: %v", err)
+	}
+	tb.SetInputMode(tb.InputEsc)
+	suspended = false
+	renderMu.Unlock()
+
+	render(ctx.Tabs, ctx.Grid, ctx.LogTables[ctx.Tabs.ActiveTabIndex], rightPane(ctx))
+	renderBottom(ctx)
+	return err
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// pipeOutput waits until the pipe command finished and returns its output.
+func pipeOutput(h *harness) []string {
+	h.t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var rows []string
+		h.run(func() { rows = h.ctx.OutputView.Rows })
+		if !slices.Equal(rows, []string{"Running..."}) {
+			return rows
+		}
+		if time.Now().After(deadline) {
+			h.t.Fatal("the pipe command didn't finish")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestPipeKey(t *testing.T) {
+	h := newHarness(t, LogConfig{Title: "api", EntryPattern: `^\d{4}-`})
+	h.ctx.Config.Pipes = []PipeConfig{{Title: "count", Key: "c", Command: "wc -l"}, {Key: "C", Command: "tr a-z A-Z"}}
+	h.feed(0, harnessLines...)
+
+	// the selected entry, continuation lines included, without escape codes
+	h.press("<Down>", "<Down>", "C")
+	want := []string{"2024-05-01 12:00:02 ERROR REQUEST FAILED", "  AT HANDLER.GO:42", "  AT SERVER.GO:7"}
+	if got := pipeOutput(h); !slices.Equal(got, want) {
+		t.Errorf("pipe got %q, want %q", got, want)
+	}
+	if h.ctx.OutputView.Title != " | tr a-z A-Z " {
+		t.Errorf("output title %q", h.ctx.OutputView.Title)
+	}
+
+	// marked entries, the oldest first
+	h.press("<Escape>", "<Space>", "<Down>", "<Down>", "<Space>", "c")
+	if got := pipeOutput(h); len(got) != 1 || strings.TrimSpace(got[0]) != "4" {
+		t.Errorf("marked entries piped %q lines, want 4", got)
+	}
+	if h.ctx.OutputView.Title != " count " {
+		t.Errorf("output title %q", h.ctx.OutputView.Title)
+	}
+}
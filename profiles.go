@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	ui "github.com/gizak/termui/v3"
+)
+
+type ProfileConfig struct {
+	Name    string            `mapstructure:"name"`
+	Params  map[string]string `mapstructure:"params"`
+	Logs    []string          `mapstructure:"logs"`
+	Disable []string          `mapstructure:"disable"`
+}
+
+func findProfile(config *Config, name string) *ProfileConfig {
+	for i := range config.Profiles {
+		if config.Profiles[i].Name == name {
+			return &config.Profiles[i]
+		}
+	}
+	return nil
+}
+
+// selectProfile returns the profile given with --profile, or lets the user pick one
+// if the config has profiles. Returns nil if there are none, false if cancelled.
+func selectProfile(uiEvents <-chan ui.Event, config *Config, name string) (*ProfileConfig, bool, error) {
+	if name != "" {
+		profile := findProfile(config, name)
+		if profile == nil {
+			return nil, false, fmt.Errorf("unknown profile %q", name)
+		}
+		return profile, true, nil
+	}
+
+	if len(config.Profiles) == 0 {
+		return nil, true, nil
+	}
+
+	choices := []string{}
+	for _, profile := range config.Profiles {
+		choices = append(choices, profile.Name)
+	}
+	name = readRememberedProfiles()[paramsKey()]
+	name, ok := askParam(uiEvents, ParamConfig{Name: "profile", Choices: choices}, name, "")
+	if !ok {
+		return nil, false, nil
+	}
+	rememberProfile(name)
+	return findProfile(config, name), true, nil
+}
+
+// profilesFile keeps the profile picked last time, apart from the params, which may have the same name.
+func profilesFile() string {
+	if file := paramsFile(); file != "" {
+		return filepath.Join(filepath.Dir(file), "profiles.json")
+	}
+	return ""
+}
+
+// readRememberedProfiles returns the profile picked last time for each config file.
+func readRememberedProfiles() map[string]string {
+	remembered := map[string]string{}
+	if data, err := os.ReadFile(profilesFile()); err == nil {
+		json.Unmarshal(data, &remembered)
+	}
+	return remembered
+}
+
+// rememberProfile saves the picked profile for the current config file.
+func rememberProfile(name string) error {
+	file := profilesFile()
+	if file == "" {
+		return nil
+	}
+	remembered := readRememberedProfiles()
+	remembered[paramsKey()] = name
+	return writeRemembered(file, remembered)
+}
+
+// applyProfile keeps only logs enabled in the profile and adds its params
+// to the ones not given on the command line.
+func applyProfile(config *Config, profile *ProfileConfig, params map[string]string) {
+	logs := []LogConfig{}
+	for _, logConfig := range config.Logs {
+		if profile.enables(logConfig.Title) {
+			logs = append(logs, logConfig)
+		}
+	}
+	config.Logs = logs
+
+	for key, val := range profile.Params {
+		if _, ok := params[key]; !ok {
+			params[key] = val
+		}
+	}
+}
+
+func (self *ProfileConfig) enables(title string) bool {
+	if len(self.Logs) > 0 && !slices.Contains(self.Logs, title) {
+		return false
+	}
+	return !slices.Contains(self.Disable, title)
+}
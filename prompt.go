@@ -0,0 +1,112 @@
+package main
+
+import (
+	"strings"
+
+	ui "github.com/gizak/termui/v3"
+)
+
+// Prompt is a single line text input shown in the Info bar.
+type Prompt struct {
+	Label    string
+	Value    string
+	OnSubmit func(value string)
+}
+
+var promptStyle = ui.NewStyle(ui.ColorYellow)
+
+func showPrompt(ctx *Context, label string, value string, onSubmit func(string)) {
+	ctx.infoMu.Lock()
+	ctx.Prompt = &Prompt{Label: label, Value: value, OnSubmit: onSubmit}
+	ctx.infoGeneration++
+	ctx.infoMu.Unlock()
+	renderInfo(ctx)
+}
+
+// updateInfoStatus sets the status line of the Info bar from the log view and the active tab,
+// and shows it unless a message is flashed.
+func updateInfoStatus(ctx *Context) {
+	parts := []string{}
+	for _, part := range []string{columnStatus(ctx), tabStatus(ctx, ctx.Tabs.ActiveTabIndex)} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	status := strings.Join(parts, " · ")
+	ctx.infoMu.Lock()
+	changed := status != ctx.infoStatus
+	ctx.infoStatus = status
+	flashed := ctx.infoFlashed == ctx.infoGeneration
+	ctx.infoMu.Unlock()
+	if changed && !flashed {
+		renderInfo(ctx)
+	}
+}
+
+func renderInfo(ctx *Context) {
+	ctx.infoMu.Lock()
+	ctx.Info.Text = defaultInfoText(ctx)
+	if ctx.Prompt != nil {
+		ctx.Info.BorderStyle = promptStyle
+	} else {
+		ctx.Info.BorderStyle = ui.Theme.Block.Border
+	}
+	ctx.infoMu.Unlock()
+	render(ctx.Info)
+}
+
+// defaultInfoText is the Info bar text when no message is flashed.
+func defaultInfoText(ctx *Context) string {
+	if ctx.Prompt == nil && ctx.infoStatus != "" {
+		return ctx.infoStatus + "\n" + infoText
+	}
+	if ctx.Prompt == nil {
+		return infoText
+	}
+	// escape brackets, so the value isn't parsed as styles
+	value := strings.NewReplacer("[", "［", "]", "］").Replace(ctx.Prompt.Value)
+	return "[" + ctx.Prompt.Label + "](fg:yellow) " + value + "█"
+}
+
+// handlePromptKey handles all keys while a prompt is shown.
+func handlePromptKey(ctx *Context, id string) {
+	prompt := ctx.Prompt
+
+	switch id {
+	case "<Escape>", "<C-c>":
+		ctx.Prompt = nil
+
+	case "<Enter>":
+		ctx.Prompt = nil
+		renderInfo(ctx)
+		prompt.OnSubmit(prompt.Value)
+		return
+
+	default:
+		prompt.Value = editText(prompt.Value, id)
+	}
+	renderInfo(ctx)
+}
+
+// editText applies a key to a single line text value.
+func editText(value string, id string) string {
+	switch id {
+	case "<Backspace>", "<C-<Backspace>>":
+		runes := []rune(value)
+		if len(runes) > 0 {
+			return string(runes[:len(runes)-1])
+		}
+
+	case "<C-u>":
+		return ""
+
+	case "<Space>":
+		return value + " "
+
+	default:
+		if len([]rune(id)) == 1 {
+			return value + id
+		}
+	}
+	return value
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	ui "github.com/gizak/termui/v3"
+)
+
+const defaultRateWindow = time.Minute * 5
+
+type rateBucket struct {
+	second  int64
+	entries int
+	errors  int
+}
+
+// RateCounter counts entries per second, by arrival time, over a fixed window.
+type RateCounter struct {
+	buckets []rateBucket
+	mu      sync.Mutex
+}
+
+func NewRateCounter(window time.Duration) *RateCounter {
+	return &RateCounter{buckets: make([]rateBucket, rateBuckets(window))}
+}
+
+func rateBuckets(window time.Duration) int {
+	if window < time.Second {
+		window = defaultRateWindow
+	}
+	return int(window / time.Second)
+}
+
+// SetWindow changes the window, keeping the counts of the seconds still in it.
+func (self *RateCounter) SetWindow(window time.Duration) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	buckets := make([]rateBucket, rateBuckets(window))
+	for _, bucket := range self.buckets {
+		slot := &buckets[bucket.second%int64(len(buckets))]
+		if bucket.second > slot.second {
+			*slot = bucket
+		}
+	}
+	self.buckets = buckets
+}
+
+func (self *RateCounter) Add(now time.Time, isError bool) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	second := now.Unix()
+	bucket := &self.buckets[second%int64(len(self.buckets))]
+	if bucket.second != second {
+		*bucket = rateBucket{second: second}
+	}
+	bucket.entries++
+	if isError {
+		bucket.errors++
+	}
+}
+
+// Series returns entries and errors per second over the window, averaged into at most points values.
+func (self *RateCounter) Series(now time.Time, points int) ([]float64, []float64) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	size := len(self.buckets)
+	if points < 1 {
+		points = 1
+	}
+	perPoint := (size + points - 1) / points
+	count := (size + perPoint - 1) / perPoint
+
+	entries := make([]float64, count)
+	errors := make([]float64, count)
+	last := now.Unix()
+	for i := 0; i < size; i++ {
+		second := last - int64(size-1-i)
+		bucket := self.buckets[second%int64(size)]
+		if bucket.second != second {
+			continue
+		}
+		entries[i/perPoint] += float64(bucket.entries) / float64(perPoint)
+		errors[i/perPoint] += float64(bucket.errors) / float64(perPoint)
+	}
+	return entries, errors
+}
+
+// updateRateView shows the rates of the active tab up to the time.
+func updateRateView(ctx *Context, now time.Time) {
+	state := ctx.LogStates[ctx.Tabs.ActiveTabIndex]
+	entries, errors := state.Rates.Series(now, ctx.RateView.Inner.Dx())
+
+	ctx.RateView.Sparklines[0].Data = entries
+	ctx.RateView.Sparklines[1].Data = errors
+	// errors are drawn on the same scale as entries
+	ctx.RateView.Sparklines[1].MaxVal, _ = ui.GetMaxFloat64FromSlice(entries)
+
+	lastEntries, lastErrors := 0.0, 0.0
+	if len(entries) > 0 {
+		lastEntries, lastErrors = entries[len(entries)-1], errors[len(errors)-1]
+	}
+	ctx.RateView.Title = fmt.Sprintf(" %.1f/s, errors %.1f/s ", lastEntries, lastErrors)
+}
+
+func updateBottomLayout(ctx *Context) {
+	termWidth, termHeight := terminalDimensions()
+	if ctx.RatesShown {
+		split := termWidth * 2 / 3
+		ctx.Info.SetRect(0, termHeight - 4, split, termHeight)
+		ctx.RateView.SetRect(split, termHeight - 4, termWidth, termHeight)
+	} else {
+		ctx.Info.SetRect(0, termHeight - 4, termWidth, termHeight)
+	}
+}
+
+func toggleRates(ctx *Context) {
+	ctx.RatesShown = !ctx.RatesShown
+	updateBottomLayout(ctx)
+	renderBottom(ctx)
+}
+
+func renderBottom(ctx *Context) {
+	if ctx.RatesShown {
+		updateRateView(ctx, time.Now())
+		render(ctx.Info, ctx.RateView)
+	} else {
+		render(ctx.Info)
+	}
+}
+
+// refreshRates keeps the rate view moving when no new lines arrive. The view is updated on the
+// key listener goroutine, so the active tab can't be closed meanwhile.
+func refreshRates(ctx *Context) {
+	for range time.Tick(time.Second) {
+		ctx.Actions <- func() {
+			if ctx.RatesShown {
+				updateRateView(ctx, time.Now())
+				render(ctx.RateView)
+			}
+		}
+	}
+}
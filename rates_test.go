@@ -0,0 +1,44 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestRateCounterSetWindow(t *testing.T) {
+	now := time.Unix(1000, 0)
+	rates := NewRateCounter(4 * time.Second)
+	for i := 0; i < 4; i++ {
+		for j := 0; j <= i; j++ {
+			rates.Add(now.Add(time.Duration(i-3)*time.Second), false)
+		}
+	}
+
+	rates.SetWindow(2 * time.Second)
+	if entries, _ := rates.Series(now, 10); !slices.Equal(entries, []float64{3, 4}) {
+		t.Errorf("after shrinking: %v", entries)
+	}
+	rates.SetWindow(3 * time.Second)
+	if entries, _ := rates.Series(now, 10); !slices.Equal(entries, []float64{0, 3, 4}) {
+		t.Errorf("after growing: %v", entries)
+	}
+}
+
+func TestRateView(t *testing.T) {
+	h := newHarness(t, LogConfig{Title: "api", EntryPattern: `^\d{4}-`}, LogConfig{Title: "worker"})
+	h.feed(0, harnessLines...)
+	h.press("r")
+	h.run(func() {
+		updateRateView(h.ctx, harnessTime.Add(time.Second))
+		render(h.ctx.RateView)
+	})
+	h.assertScreen("harness_rates")
+
+	// the view follows the active tab, also when the last one was closed
+	h.press("<Right>", "w")
+	h.run(func() { updateRateView(h.ctx, harnessTime.Add(time.Second)) })
+	if h.ctx.RateView.Title != " 0.3/s, errors 0.1/s " {
+		t.Errorf("rate view title %q", h.ctx.RateView.Title)
+	}
+}
@@ -0,0 +1,364 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	ui "github.com/gizak/termui/v3"
+)
+
+// A recording is a text file (gzipped if the name ends with .gz) starting with a header line
+// "go-log-reader-recording 1 <start unix ms>", followed by records:
+//
+//	S <source id> <log config as JSON>
+//	L <source id> <ms since the previous line> <line>
+
+const recordingHeader = "go-log-reader-recording 1"
+
+// Recorder writes all lines read by the tabs to a recording.
+type Recorder struct {
+	mu      sync.Mutex
+	file    *os.File
+	gzip    *gzip.Writer
+	writer  *bufio.Writer
+	last    time.Time
+	sources map[*LogState]int
+}
+
+func newRecorder(path string) (*Recorder, error) {
+	file, err := os.Create(expandHome(path))
+	if err != nil {
+		return nil, err
+	}
+	self := &Recorder{file: file, sources: map[*LogState]int{}, last: time.Now()}
+	if strings.HasSuffix(path, ".gz") {
+		self.gzip = gzip.NewWriter(file)
+		self.writer = bufio.NewWriter(self.gzip)
+	} else {
+		self.writer = bufio.NewWriter(file)
+	}
+	fmt.Fprintf(self.writer, "%s %d\n", recordingHeader, self.last.UnixMilli())
+
+	go func() {
+		for range time.Tick(time.Second) {
+			self.mu.Lock()
+			if self.writer == nil {
+				self.mu.Unlock()
+				return
+			}
+			self.writer.Flush()
+			self.mu.Unlock()
+		}
+	}()
+	return self, nil
+}
+
+// source records the config of a log, when it's started or restarted. Does nothing when not recording.
+func (self *Recorder) source(state *LogState, logConfig LogConfig) {
+	if self == nil {
+		return
+	}
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	if self.writer == nil {
+		return
+	}
+	id, ok := self.sources[state]
+	if !ok {
+		id = len(self.sources)
+		self.sources[state] = id
+	}
+	data, _ := json.Marshal(logConfig)
+	fmt.Fprintf(self.writer, "S %d %s\n", id, data)
+}
+
+// line records a line read by the log. Does nothing when not recording.
+func (self *Recorder) line(state *LogState, now time.Time, line string) {
+	if self == nil {
+		return
+	}
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	if self.writer == nil {
+		return
+	}
+	fmt.Fprintf(self.writer, "L %d %d %s\n", self.sources[state], now.Sub(self.last).Milliseconds(), line)
+	self.last = self.last.Add(now.Sub(self.last).Truncate(time.Millisecond))
+}
+
+func (self *Recorder) Close() error {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	err := self.writer.Flush()
+	self.writer = nil
+	if self.gzip != nil {
+		if gzipErr := self.gzip.Close(); err == nil {
+			err = gzipErr
+		}
+	}
+	if closeErr := self.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// recordingReader reads the records of a recording.
+type recordingReader struct {
+	scanner *bufio.Scanner
+	closers []io.Closer
+	time    time.Time
+}
+
+type record struct {
+	source    int
+	logConfig LogConfig
+	line      string
+	delta     time.Duration
+	isLine    bool
+}
+
+func openRecording(path string) (*recordingReader, error) {
+	file, err := os.Open(expandHome(path))
+	if err != nil {
+		return nil, err
+	}
+	self := &recordingReader{closers: []io.Closer{file}}
+	var input io.Reader = file
+	if strings.HasSuffix(path, ".gz") {
+		gzipReader, err := gzip.NewReader(file)
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		self.closers = append(self.closers, gzipReader)
+		input = gzipReader
+	}
+	self.scanner = bufio.NewScanner(input)
+	self.scanner.Buffer(nil, 1024*1024)
+
+	header, ok := self.next()
+	start, err := strconv.ParseInt(strings.TrimPrefix(header, recordingHeader+" "), 10, 64)
+	if !ok || !strings.HasPrefix(header, recordingHeader+" ") || err != nil {
+		self.Close()
+		return nil, fmt.Errorf("%s: not a recording", path)
+	}
+	self.time = time.UnixMilli(start)
+	return self, nil
+}
+
+func (self *recordingReader) next() (string, bool) {
+	if !self.scanner.Scan() {
+		return "", false
+	}
+	return self.scanner.Text(), true
+}
+
+// read returns the next record, false at the end of the recording.
+func (self *recordingReader) read() (record, bool, error) {
+	text, ok := self.next()
+	if !ok {
+		return record{}, false, self.scanner.Err()
+	}
+	kind, rest, _ := strings.Cut(text, " ")
+	sourceText, rest, _ := strings.Cut(rest, " ")
+	source, err := strconv.Atoi(sourceText)
+	if err != nil {
+		return record{}, false, fmt.Errorf("invalid record %q", text)
+	}
+
+	switch kind {
+	case "S":
+		result := record{source: source}
+		err := json.Unmarshal([]byte(rest), &result.logConfig)
+		return result, true, err
+
+	case "L":
+		deltaText, line, _ := strings.Cut(rest, " ")
+		delta, err := strconv.ParseInt(deltaText, 10, 64)
+		result := record{source: source, line: line, delta: time.Duration(delta) * time.Millisecond, isLine: true}
+		return result, true, err
+	}
+	return record{}, false, fmt.Errorf("invalid record %q", text)
+}
+
+func (self *recordingReader) Close() error {
+	for i := len(self.closers) - 1; i >= 0; i-- {
+		self.closers[i].Close()
+	}
+	return nil
+}
+
+// Replay feeds the lines of a recording to the tabs, with the recorded timing.
+type Replay struct {
+	path string
+	// Logs are the configs of the recorded logs, by source id
+	Logs    []LogConfig
+	sources []*lineSource
+
+	mu     sync.Mutex
+	paused bool
+	// speed is 0 to replay without waiting
+	speed float64
+}
+
+var replaySpeeds = []float64{1, 10, 0}
+
+func parseSpeed(value string) (float64, error) {
+	switch value {
+	case "", "1", "1x":
+		return 1, nil
+	case "instant", "0":
+		return 0, nil
+	}
+	speed, err := strconv.ParseFloat(strings.TrimSuffix(value, "x"), 64)
+	if err != nil || speed < 0 {
+		return 0, fmt.Errorf("invalid speed %q, expected a number like 10 or instant", value)
+	}
+	return speed, nil
+}
+
+// openReplay reads the recorded logs, which become the tabs of the reader.
+func openReplay(path string, speed float64) (*Replay, error) {
+	reader, err := openRecording(path)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	self := &Replay{path: path, speed: speed}
+	for {
+		record, ok, err := reader.read()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		if !ok {
+			break
+		}
+		// a restarted log is recorded again, the first config is used
+		if !record.isLine && record.source == len(self.Logs) {
+			self.Logs = append(self.Logs, record.logConfig)
+		}
+	}
+	if len(self.Logs) == 0 {
+		return nil, fmt.Errorf("%s: no logs recorded", path)
+	}
+	return self, nil
+}
+
+// start opens the tabs of the recorded logs and starts feeding them.
+func (self *Replay) start(ctx *Context) {
+	for _, logConfig := range self.Logs {
+		source := newLineSource()
+		source.instant = func() bool { return self.currentSpeed() == 0 }
+		self.sources = append(self.sources, source)
+		logConfig.source = source
+		openTab(ctx, logConfig, false)
+	}
+	go self.run(ctx)
+}
+
+func (self *Replay) run(ctx *Context) {
+	reader, err := openRecording(self.path)
+	if err != nil {
+		flashInfo(ctx, fmt.Sprintf("Replay failed: %v", err), alertInfoStyle)
+		return
+	}
+	defer reader.Close()
+
+	now := reader.time
+	for {
+		record, ok, err := reader.read()
+		if err != nil {
+			flashInfo(ctx, fmt.Sprintf("Replay failed: %v", err), alertInfoStyle)
+		}
+		if !ok || err != nil {
+			break
+		}
+		if !record.isLine || record.source >= len(self.sources) {
+			continue
+		}
+		self.wait(record.delta)
+		now = now.Add(record.delta)
+		self.sources[record.source].add([]byte(record.line+"\n"), now)
+	}
+
+	for _, source := range self.sources {
+		source.end(io.EOF)
+	}
+	flashInfo(ctx, "Replay ended", ui.Theme.Block.Border)
+}
+
+// wait sleeps for the recorded time at the current speed, while not paused.
+func (self *Replay) wait(left time.Duration) {
+	const tick = time.Millisecond * 100
+	for {
+		self.mu.Lock()
+		paused, speed := self.paused, self.speed
+		self.mu.Unlock()
+
+		if paused {
+			time.Sleep(tick)
+			continue
+		}
+		if speed == 0 || left <= 0 {
+			return
+		}
+		step := min(left, time.Duration(float64(tick)*speed))
+		time.Sleep(time.Duration(float64(step) / speed))
+		left -= step
+	}
+}
+
+func (self *Replay) currentSpeed() float64 {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	return self.speed
+}
+
+func speedName(speed float64) string {
+	if speed == 0 {
+		return "instant"
+	}
+	return strconv.FormatFloat(speed, 'f', -1, 64) + "x"
+}
+
+func toggleReplayPause(ctx *Context) {
+	self := ctx.Replay
+	self.mu.Lock()
+	self.paused = !self.paused
+	paused := self.paused
+	self.mu.Unlock()
+
+	if paused {
+		flashInfo(ctx, "Replay paused", promptStyle)
+	} else {
+		flashInfo(ctx, "Replay resumed", ui.Theme.Block.Border)
+	}
+}
+
+// nextReplaySpeed switches between 1x, 10x and instant replay.
+func nextReplaySpeed(ctx *Context) {
+	self := ctx.Replay
+	self.mu.Lock()
+	next := replaySpeeds[0]
+	for i, speed := range replaySpeeds {
+		if speed == self.speed && i+1 < len(replaySpeeds) {
+			next = replaySpeeds[i+1]
+		}
+	}
+	self.speed = next
+	self.mu.Unlock()
+
+	flashInfo(ctx, "Replay speed "+speedName(next), ui.Theme.Block.Border)
+}
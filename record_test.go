@@ -0,0 +1,51 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReplayGoesThroughListener(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "recording")
+	recording := recordingHeader + " 1714564800000\n" +
+		`S 0 {"Title":"api","Command":"./api"}` + "\n" +
+		"L 0 0 first\n" +
+		"L 0 1500 second\n"
+	if err := os.WriteFile(path, []byte(recording), 0644); err != nil {
+		t.Fatal(err)
+	}
+	replay, err := openReplay(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the harness needs a tab to lay out
+	h := newHarness(t, LogConfig{Title: "live"})
+	h.ctx.Replay = replay
+	replay.start(h.ctx)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		h.ctx.tabsMu.Lock()
+		ended := h.ctx.LogStates[1].Ended
+		h.ctx.tabsMu.Unlock()
+		if ended {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the replayed tab didn't end")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	entries := h.ctx.LogStates[1].Entries
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+	// the newest entry is first, with the recorded time
+	if got, want := entries[0].FirstSeen, time.UnixMilli(1714564801500); !got.Equal(want) {
+		t.Errorf("second line seen at %v, want %v", got, want)
+	}
+}
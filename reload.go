@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+
+	"github.com/fsnotify/fsnotify"
+	ui "github.com/gizak/termui/v3"
+	"github.com/spf13/viper"
+)
+
+// watchConfig reloads the config whenever the config file changes.
+func watchConfig(ctx *Context) {
+	viper.OnConfigChange(func(fsnotify.Event) {
+		ctx.Actions <- func() { reloadConfig(ctx) }
+	})
+	viper.WatchConfig()
+}
+
+// configTabIndex finds the tab opened for the log with the title in the config file, or -1.
+func configTabIndex(ctx *Context, title string) int {
+	return slices.IndexFunc(ctx.LogStates, func(state *LogState) bool { return state.configTitle == title })
+}
+
+// reloadConfig reads the config file again and applies it to the running tabs, matched by title:
+// new logs are opened, removed ones are closed and logs with a changed command or file are restarted.
+// Display settings, like the rate window and library frames, apply to the open tabs.
+// An invalid config is reported and ignored.
+func reloadConfig(ctx *Context) {
+	config, problems := readConfig()
+	params := map[string]string{}
+	for key, val := range ctx.params {
+		params[key] = val
+	}
+
+	if config != nil {
+		problems = append(problems, validateProfiles(config)...)
+	}
+	if config != nil && ctx.profile != "" {
+		if profile := findProfile(config, ctx.profile); profile != nil {
+			applyProfile(config, profile, params)
+		} else {
+			problems = append(problems, ConfigProblem{File: viper.ConfigFileUsed(), Message: fmt.Sprintf("unknown profile %q", ctx.profile)})
+		}
+	}
+	if config != nil {
+		// params added since startup get their remembered or default value
+		remembered := readRememberedParams()[paramsKey()]
+		for _, param := range configParams(config) {
+			if _, ok := params[param.Name]; ok {
+				continue
+			}
+			if value, ok := remembered[param.Name]; ok {
+				params[param.Name] = value
+			} else if param.Default != "" {
+				params[param.Name] = param.Default
+			} else {
+				problems = append(problems, ConfigProblem{File: viper.ConfigFileUsed(), Message: fmt.Sprintf("new param %q has no value, restart to set it", param.Name)})
+			}
+		}
+		problems = append(problems, validateConfig(config, params)...)
+	}
+
+	if len(problems) > 0 {
+		flashInfo(ctx, fmt.Sprintf("Config not reloaded: %s", problems[0]), alertInfoStyle)
+		return
+	}
+	applyParams(config, params)
+
+	for _, logConfig := range config.Logs {
+		index := configTabIndex(ctx, logConfig.Title)
+		if index == -1 {
+			openTab(ctx, logConfig, true)
+			continue
+		}
+		if ctx.LogStates[index].configTitle != ctx.Config.Logs[index].Title {
+			// keep the title the tab was renamed to
+			logConfig.Title = ctx.Config.Logs[index].Title
+		}
+		if !reflect.DeepEqual(ctx.Config.Logs[index], logConfig) {
+			updateTab(ctx, index, logConfig)
+		}
+	}
+	// tabs opened in the app are kept
+	for i := len(ctx.LogStates) - 1; i >= 0; i-- {
+		title := ctx.LogStates[i].configTitle
+		if title != "" && !slices.ContainsFunc(config.Logs, func(logConfig LogConfig) bool { return logConfig.Title == title }) {
+			closeTab(ctx, i)
+		}
+	}
+
+	if config.RateWindow != ctx.Config.RateWindow {
+		for _, state := range ctx.LogStates {
+			state.Rates.SetWindow(config.RateWindow)
+		}
+	}
+	logs := ctx.Config.Logs
+	*ctx.Config = *config
+	ctx.Config.Logs = logs
+	ctx.Alerts = newAlerts(config.Alerts)
+
+	redrawTabs(ctx)
+	flashInfo(ctx, fmt.Sprintf("Config reloaded, %d logs", len(ctx.Config.Logs)), ui.Theme.Block.Border)
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	ui "github.com/gizak/termui/v3"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// savedLogs returns the logs of the config file updated with the open tabs. Logs read from the file keep
+// their placeholders unless the command was changed, logs hidden by the profile are kept,
+// closed ones are removed and tabs opened in the app are added.
+func savedLogs(ctx *Context, fileLogs []LogConfig) []LogConfig {
+	profile := findProfile(ctx.Config, ctx.profile)
+	saved := map[*LogState]bool{}
+	logs := []LogConfig{}
+
+	for _, fileLog := range fileLogs {
+		index := configTabIndex(ctx, fileLog.Title)
+		if index == -1 {
+			if profile != nil && !profile.enables(fileLog.Title) {
+				logs = append(logs, fileLog)
+			}
+			continue
+		}
+
+		logConfig := ctx.Config.Logs[index]
+		resolved := &Config{Logs: []LogConfig{fileLog}}
+		applyParams(resolved, ctx.params)
+		if resolved.Logs[0].Command == logConfig.Command {
+			logConfig.Command = fileLog.Command
+		}
+		logs = append(logs, logConfig)
+		saved[ctx.LogStates[index]] = true
+	}
+
+	for i, state := range ctx.LogStates {
+		if !saved[state] {
+			logs = append(logs, ctx.Config.Logs[i])
+		}
+	}
+	return logs
+}
+
+// saveTabs replaces the logs of the config file with the open tabs, keeping the rest of the file.
+func saveTabs(ctx *Context, file string) error {
+	file = expandHome(file)
+	doc := &yaml.Node{}
+	if data, err := os.ReadFile(file); err == nil {
+		if err := yaml.Unmarshal(data, doc); err != nil {
+			return err
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	if len(doc.Content) == 0 {
+		doc = &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("%s: expected a mapping at the top level", file)
+	}
+
+	var logsNode *yaml.Node
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "logs" {
+			logsNode = root.Content[i+1]
+		}
+	}
+	if logsNode == nil {
+		logsNode = &yaml.Node{}
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: "logs"}, logsNode)
+	}
+
+	fileLogs := []LogConfig{}
+	if err := logsNode.Decode(&fileLogs); err != nil && logsNode.Kind != 0 {
+		return err
+	}
+
+	if err := logsNode.Encode(savedLogs(ctx, fileLogs)); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if strings.EqualFold(filepath.Ext(file), ".json") {
+		// a JSON config stays JSON, the file is parsed as yaml to keep the order of its keys
+		var compact bytes.Buffer
+		if err := writeNodeJSON(&compact, root); err != nil {
+			return err
+		}
+		if err := json.Indent(&buf, compact.Bytes(), "", "  "); err != nil {
+			return err
+		}
+		buf.WriteByte('\n')
+	} else {
+		encoder := yaml.NewEncoder(&buf)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(doc); err != nil {
+			return err
+		}
+	}
+	if err := os.WriteFile(file, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+
+	// saved tabs now belong to the file, so a reload keeps them
+	for i, state := range ctx.LogStates {
+		state.configTitle = ctx.Config.Logs[i].Title
+	}
+	return nil
+}
+
+// writeNodeJSON writes the yaml node as compact JSON.
+func writeNodeJSON(buf *bytes.Buffer, node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			buf.WriteString("null")
+			return nil
+		}
+		return writeNodeJSON(buf, node.Content[0])
+
+	case yaml.AliasNode:
+		return writeNodeJSON(buf, node.Alias)
+
+	case yaml.MappingNode:
+		buf.WriteByte('{')
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeJSONString(buf, node.Content[i].Value)
+			buf.WriteByte(':')
+			if err := writeNodeJSON(buf, node.Content[i+1]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+
+	case yaml.SequenceNode:
+		buf.WriteByte('[')
+		for i, item := range node.Content {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeNodeJSON(buf, item); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+
+	default:
+		switch node.ShortTag() {
+		case "!!null":
+			buf.WriteString("null")
+		case "!!bool":
+			var value bool
+			if err := node.Decode(&value); err != nil {
+				return err
+			}
+			buf.WriteString(strconv.FormatBool(value))
+		case "!!int", "!!float":
+			if json.Valid([]byte(node.Value)) {
+				buf.WriteString(node.Value)
+			} else {
+				writeJSONString(buf, node.Value)
+			}
+		default:
+			writeJSONString(buf, node.Value)
+		}
+	}
+	return nil
+}
+
+func writeJSONString(buf *bytes.Buffer, value string) {
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(value)
+	// Encode adds a newline
+	buf.Truncate(buf.Len() - 1)
+}
+
+func promptSaveTabs(ctx *Context) {
+	file := viper.ConfigFileUsed()
+	if file == "" {
+		file = ".go-log-reader.yaml"
+	}
+	showPrompt(ctx, "Save tabs to config:", file, func(file string) {
+		if file == "" {
+			return
+		}
+		if err := saveTabs(ctx, file); err != nil {
+			flashInfo(ctx, fmt.Sprintf("Save failed: %v", err), alertInfoStyle)
+		} else {
+			flashInfo(ctx, fmt.Sprintf("Saved %d tabs to [%s](fg:cyan)", len(ctx.LogStates), file), ui.Theme.Block.Border)
+		}
+	})
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// openSource starts reading the log, returns its output and the started command (nil for stdin).
+// Errors of the command are written to stderr, if given.
+func openSource(logConfig LogConfig, stderr io.Writer) (io.Reader, *exec.Cmd, error) {
+	if logConfig.source != nil {
+		return logConfig.source.reader(), nil, nil
+	}
+	if logConfig.Stdin {
+		return stdinSource().reader(), nil, nil
+	}
+
+	var cmd *exec.Cmd
+	if logConfig.File != "" {
+		cmd = exec.Command("tail", "-n", "1000", "-F", expandHome(logConfig.File))
+	} else {
+		cmdArr := strings.Split(logConfig.Command, " ")
+		cmd = exec.Command(cmdArr[0], cmdArr[1:]...)
+	}
+	cmd.Stderr = stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return strings.NewReader(""), nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return strings.NewReader(""), nil, err
+	}
+	return stdout, cmd, nil
+}
+
+// stdinIsTerminal is true when nothing is piped to the standard input.
+func stdinIsTerminal() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// stdin is read once for the whole run. Its tab can be closed or restarted, and the next
+// one goes on with the lines the previous one didn't get to.
+var stdin struct {
+	once   sync.Once
+	source *lineSource
+}
+
+func stdinSource() *lineSource {
+	stdin.once.Do(func() {
+		stdin.source = newLineSource()
+		go readLines(stdin.source, os.Stdin)
+	})
+	return stdin.source
+}
+
+// readLines adds the lines of the input to the source, until its end.
+func readLines(source *lineSource, input io.Reader) {
+	buffered := bufio.NewReader(input)
+	for {
+		line, err := buffered.ReadBytes('\n')
+		if len(line) > 0 {
+			source.add(line, time.Now())
+		}
+		if err != nil {
+			source.end(err)
+			return
+		}
+	}
+}
+
+// lineSource hands out lines read elsewhere to its newest reader, a line per Read, so a
+// scanner never buffers lines ahead. A line only counts as read when the same reader asks
+// for the next one, so the line a closed or restarted tab got but didn't add goes to the
+// next reader.
+type lineSource struct {
+	mu    sync.Mutex
+	cond  *sync.Cond
+	lines []sourceLine
+	// given is how much of the first line the owner got
+	given int
+	err   error
+	owner *lineReader
+	// instant is set when the lines come faster than they can be drawn
+	instant func() bool
+}
+
+type sourceLine struct {
+	text []byte
+	time time.Time
+}
+
+func newLineSource() *lineSource {
+	self := &lineSource{}
+	self.cond = sync.NewCond(&self.mu)
+	return self
+}
+
+// add adds a line, ending with a newline unless it's the last one, read at the time.
+func (self *lineSource) add(text []byte, at time.Time) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	self.lines = append(self.lines, sourceLine{text: text, time: at})
+	self.cond.Broadcast()
+}
+
+// end makes the readers return the error, io.EOF for a normal end, once all lines are read.
+func (self *lineSource) end(err error) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	self.err = err
+	self.cond.Broadcast()
+}
+
+// reader returns a reader of the lines, and ends the previous one.
+func (self *lineSource) reader() io.Reader {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	self.owner = &lineReader{source: self}
+	self.given = 0
+	self.cond.Broadcast()
+	return self.owner
+}
+
+// lineReader reads the lines of a source, see lineSource.
+type lineReader struct {
+	source *lineSource
+	// time is when the line last read was read from the input
+	time time.Time
+}
+
+func (self *lineReader) Read(p []byte) (int, error) {
+	source := self.source
+	source.mu.Lock()
+	defer source.mu.Unlock()
+
+	for {
+		if source.owner != self {
+			return 0, io.EOF
+		}
+		if len(source.lines) > 0 && source.given == len(source.lines[0].text) {
+			source.lines = source.lines[1:]
+			source.given = 0
+		}
+		if len(source.lines) > 0 {
+			line := source.lines[0]
+			n := copy(p, line.text[source.given:])
+			source.given += n
+			self.time = line.time
+			return n, nil
+		}
+		if source.err != nil {
+			return 0, source.err
+		}
+		source.cond.Wait()
+	}
+}
+
+// lineTime returns when the line last read was read from the input, and whether it should
+// be drawn right away.
+func (self *lineReader) lineTime() (time.Time, bool) {
+	instant := self.source.instant != nil && self.source.instant()
+	return self.time, !instant
+}
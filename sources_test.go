@@ -0,0 +1,33 @@
+package main
+
+import (
+	"bufio"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestLineSourceGoesOnInNextReader(t *testing.T) {
+	source := newLineSource()
+	go readLines(source, strings.NewReader("one\ntwo\nthree\nfour"))
+
+	first := bufio.NewScanner(source.reader())
+	for _, want := range []string{"one", "two"} {
+		if !first.Scan() || first.Text() != want {
+			t.Fatalf("first reader got %q, want %q", first.Text(), want)
+		}
+	}
+
+	// the tab of the first reader was closed before it added "two"
+	second := bufio.NewScanner(source.reader())
+	if first.Scan() {
+		t.Errorf("first reader got %q after the second one started", first.Text())
+	}
+	lines := []string{}
+	for second.Scan() {
+		lines = append(lines, second.Text())
+	}
+	if want := []string{"two", "three", "four"}; !slices.Equal(lines, want) {
+		t.Errorf("second reader got %q, want %q", lines, want)
+	}
+}
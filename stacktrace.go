@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	ui "github.com/gizak/termui/v3"
+	customWidgets "replika.com/log-reader/widgets"
+)
+
+type StackTraceConfig struct {
+	// LibraryPrefixes mark frames of frameworks and libraries, by the package or function name
+	// of the frame, or by a path fragment containing a / for Python files
+	LibraryPrefixes []string `mapstructure:"library_prefixes"`
+}
+
+var defaultLibraryPrefixes = []string{
+	"java.", "javax.", "jdk.", "sun.", "com.sun.", "kotlin.", "scala.",
+	"org.springframework.", "org.apache.", "org.hibernate.", "io.netty.",
+	"/site-packages/", "/dist-packages/", "/lib/python", "<frozen ",
+	"runtime.", "runtime/", "net/http.", "reflect.", "testing.",
+}
+
+var javaFrameRe = regexp.MustCompile(`^\s+at\s+([\w$.<>/]+)\(`)
+var javaMoreRe = regexp.MustCompile(`^\s+\.\.\. \d+ (more|common frames omitted)`)
+var pythonFrameRe = regexp.MustCompile(`^\s+File "([^"]+)", line \d+`)
+var goFuncRe = regexp.MustCompile(`^(created by )?([\w./*()-]+\.[\w.*()-]+)(\(.*\))?( in goroutine \d+)?$`)
+var goFileRe = regexp.MustCompile(`^\t\S+\.go:\d+`)
+
+const dimColor = "\x1b[38;5;244m"
+
+// stackFrame is a frame of a stack trace in the lines of an entry.
+type stackFrame struct {
+	// lines is the number of lines of the frame
+	lines int
+	// location is the function or the file of the frame, empty for summaries like "... 5 more"
+	location string
+}
+
+// parseFrame returns the frame starting at the line, or a frame of 0 lines.
+func parseFrame(lines []string, i int) stackFrame {
+	line := customWidgets.StripAsciiCodes(lines[i])
+	next := ""
+	if i+1 < len(lines) {
+		next = customWidgets.StripAsciiCodes(lines[i+1])
+	}
+
+	if match := javaFrameRe.FindStringSubmatch(line); match != nil {
+		return stackFrame{lines: 1, location: match[1]}
+	}
+	if javaMoreRe.MatchString(line) {
+		return stackFrame{lines: 1}
+	}
+	if match := pythonFrameRe.FindStringSubmatch(line); match != nil {
+		frame := stackFrame{lines: 1, location: match[1]}
+		// the source line of the frame is indented further
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		if next != "" && len(next)-len(strings.TrimLeft(next, " ")) > indent && !pythonFrameRe.MatchString(next) {
+			frame.lines++
+		}
+		return frame
+	}
+	if match := goFuncRe.FindStringSubmatch(line); match != nil && goFileRe.MatchString(next) {
+		return stackFrame{lines: 2, location: match[2]}
+	}
+	return stackFrame{}
+}
+
+func isLibraryFrame(frame stackFrame, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(frame.location, prefix) || strings.Contains(prefix, "/") && strings.Contains(frame.location, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// foldStackTraces folds the frames of stack traces in the rows of the log view, keeping the first frame
+// of the app. When expanded, all frames are shown and library frames are dimmed.
+// It returns the rows and the text copied for each of them, which includes the folded frames.
+func foldStackTraces(rows []string, expanded bool, prefixes []string) ([]string, []string) {
+	if len(prefixes) == 0 {
+		prefixes = defaultLibraryPrefixes
+	}
+	folded := []string{}
+	texts := []string{}
+	add := func(row string, text string) {
+		folded = append(folded, row)
+		texts = append(texts, text)
+	}
+
+	// hidden holds the lines of consecutive folded frames
+	hidden := []string{}
+	hiddenFrames := 0
+	flush := func() {
+		if hiddenFrames == 1 && len(hidden) == 1 {
+			add(hidden[0], hidden[0])
+		} else if hiddenFrames > 0 {
+			noun := "frames"
+			if hiddenFrames == 1 {
+				noun = "frame"
+			}
+			add(fmt.Sprintf("%s    … %d %s folded (s to expand)\x1b[39m", dimColor, hiddenFrames, noun), strings.Join(hidden, "\n"))
+		}
+		hidden = hidden[:0]
+		hiddenFrames = 0
+	}
+
+	appFrameShown := false
+	for i := 0; i < len(rows); {
+		frame := parseFrame(rows, i)
+		if frame.lines == 0 {
+			flush()
+			// a new trace may start, e.g. "Caused by:"
+			appFrameShown = false
+			add(rows[i], rows[i])
+			i++
+			continue
+		}
+
+		frameRows := rows[i : i+frame.lines]
+		library := frame.location == "" || isLibraryFrame(frame, prefixes)
+		switch {
+		case expanded && library:
+			for _, row := range frameRows {
+				add(dimColor+customWidgets.StripAsciiCodes(row)+"\x1b[39m", row)
+			}
+		case expanded || !library && !appFrameShown:
+			flush()
+			appFrameShown = appFrameShown || !library
+			for _, row := range frameRows {
+				add(row, row)
+			}
+		default:
+			hidden = append(hidden, frameRows...)
+			hiddenFrames++
+		}
+		i += frame.lines
+	}
+	flush()
+	return folded, texts
+}
+
+func toggleStackTraces(ctx *Context) {
+	ctx.FramesExpanded = !ctx.FramesExpanded
+	setViewText(ctx)
+	render(rightPane(ctx))
+	if ctx.FramesExpanded {
+		flashInfo(ctx, "Stack frames expanded, library frames are dimmed", ui.Theme.Block.Border)
+	} else {
+		flashInfo(ctx, "Stack frames folded", ui.Theme.Block.Border)
+	}
+}
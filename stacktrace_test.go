@@ -0,0 +1,109 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	customWidgets "replika.com/log-reader/widgets"
+)
+
+var javaTrace = []string{
+	"2024-05-01 12:00:00 ERROR request failed",
+	"java.lang.IllegalStateException: no session",
+	"\tat org.springframework.web.Filter.doFilter(Filter.java:10)",
+	"\tat org.springframework.web.Chain.next(Chain.java:20)",
+	"\tat com.example.api.SessionService.load(SessionService.java:42)",
+	"\tat com.example.api.Controller.get(Controller.java:7)",
+	"\tat java.base/java.lang.Thread.run(Thread.java:833)",
+	"Caused by: java.io.IOException: closed",
+	"\tat com.example.db.Pool.take(Pool.java:3)",
+	"\t... 12 more",
+}
+
+var pythonTrace = []string{
+	"Traceback (most recent call last):",
+	`  File "/usr/lib/python3.12/site-packages/flask/app.py", line 1, in wsgi_app`,
+	"    response = self.full_dispatch_request()",
+	`  File "/srv/app/views.py", line 12, in index`,
+	"    return load()",
+	`  File "/srv/app/store.py", line 3, in load`,
+	"    raise KeyError(key)",
+	"KeyError: 'user'",
+}
+
+var goTrace = []string{
+	"panic: runtime error: index out of range [3] with length 3",
+	"",
+	"goroutine 1 [running]:",
+	"main.lookup(...)",
+	"\t/srv/app/main.go:12",
+	"main.main()",
+	"\t/srv/app/main.go:7 +0x1d",
+	"exit status 2",
+}
+
+func TestFoldStackTraces(t *testing.T) {
+	tests := []struct {
+		name     string
+		rows     []string
+		expanded bool
+		want     []string
+	}{
+		{"java folded", javaTrace, false, []string{
+			"2024-05-01 12:00:00 ERROR request failed",
+			"java.lang.IllegalStateException: no session",
+			"    … 2 frames folded (s to expand)",
+			"\tat com.example.api.SessionService.load(SessionService.java:42)",
+			"    … 2 frames folded (s to expand)",
+			"Caused by: java.io.IOException: closed",
+			"\tat com.example.db.Pool.take(Pool.java:3)",
+			"\t... 12 more",
+		}},
+		{"java expanded", javaTrace, true, javaTrace},
+		{"python folded", pythonTrace, false, []string{
+			"Traceback (most recent call last):",
+			"    … 1 frame folded (s to expand)",
+			`  File "/srv/app/views.py", line 12, in index`,
+			"    return load()",
+			"    … 1 frame folded (s to expand)",
+			"KeyError: 'user'",
+		}},
+		{"go folded", goTrace, false, []string{
+			"panic: runtime error: index out of range [3] with length 3",
+			"",
+			"goroutine 1 [running]:",
+			"main.lookup(...)",
+			"\t/srv/app/main.go:12",
+			"    … 1 frame folded (s to expand)",
+			"exit status 2",
+		}},
+		{"no trace", []string{"plain", "lines"}, false, []string{"plain", "lines"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rows, texts := foldStackTraces(test.rows, test.expanded, nil)
+			got := []string{}
+			for _, row := range rows {
+				got = append(got, customWidgets.StripAsciiCodes(row))
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got rows\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(test.want, "\n"))
+			}
+			// copying every row gives the whole entry
+			if copied := strings.Join(texts, "\n"); copied != strings.Join(test.rows, "\n") {
+				t.Errorf("copied text\n%s\nis not the entry", copied)
+			}
+		})
+	}
+}
+
+func TestLibraryFrames(t *testing.T) {
+	rows, _ := foldStackTraces(javaTrace, true, []string{"com.example.db."})
+	for i, row := range rows {
+		dimmed := strings.HasPrefix(row, dimColor)
+		if want := i >= 8; dimmed != want {
+			t.Errorf("row %q dimmed: %v, want %v", row, dimmed, want)
+		}
+	}
+}
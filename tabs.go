@@ -0,0 +1,245 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	customWidgets "replika.com/log-reader/widgets"
+)
+
+// Tabs are only added, changed or closed on the key listener goroutine (see Context.Actions),
+// which holds tabsMu. Log listeners and timers hold it while handling a line, to find their tab
+// by its state.
+
+func newLogTable(state *LogState) *customWidgets.RawTable {
+	termWidth, _ := terminalDimensions()
+
+	logTable := customWidgets.NewRawTable()
+	logTable.PaddingRight = 1
+	logTable.Border = false
+	logTable.FillRow = true
+	logTable.SeparatorStyle = rowSeparatorStyle
+	logTable.ColumnWidths = []int{termWidth / 2}
+	logTable.RowMarked = func(row int) bool {
+		return row < len(state.Visible) && state.Visible[row].Marked
+	}
+	return logTable
+}
+
+// openTab adds a tab for the log and starts reading it, returns the tab index.
+// fromConfig is set for logs of the config file, so they can be matched on reload and save.
+func openTab(ctx *Context, logConfig LogConfig, fromConfig bool) int {
+	state := addTab(ctx, logConfig, fromConfig)
+	go listenLog(ctx, state)
+	return len(ctx.LogStates) - 1
+}
+
+// addTab adds a tab for the log without reading it.
+func addTab(ctx *Context, logConfig LogConfig, fromConfig bool) *LogState {
+	state := NewLogState(ctx.Config.RateWindow)
+	state.filterRe = compileFilter(logConfig.Filter)
+	if fromConfig {
+		state.configTitle = logConfig.Title
+	}
+	ctx.Config.Logs = append(ctx.Config.Logs, logConfig)
+	ctx.LogStates = append(ctx.LogStates, state)
+	ctx.LogTables = append(ctx.LogTables, newLogTable(state))
+	ctx.Tabs.TabNames = append(ctx.Tabs.TabNames, logConfig.Title)
+	return state
+}
+
+// closeTab kills the log process and removes its tab.
+func closeTab(ctx *Context, index int) {
+	closed := ctx.LogStates[index]
+	stopLog(closed)
+	ctx.Config.Logs = append(ctx.Config.Logs[:index:index], ctx.Config.Logs[index+1:]...)
+	ctx.LogStates = append(ctx.LogStates[:index:index], ctx.LogStates[index+1:]...)
+	ctx.LogTables = append(ctx.LogTables[:index:index], ctx.LogTables[index+1:]...)
+	ctx.Tabs.TabNames = append(ctx.Tabs.TabNames[:index:index], ctx.Tabs.TabNames[index+1:]...)
+
+	active := ctx.Tabs.ActiveTabIndex
+	if active > index || active == len(ctx.LogStates) {
+		ctx.Tabs.ActiveTabIndex--
+	}
+	if active == index {
+		ctx.VisualAnchor = nil
+		ctx.ActiveRow = -1
+		ctx.LogTables[ctx.Tabs.ActiveTabIndex].ActiveRowIndex = ctx.ActiveRow
+	}
+	// duplicates get no more lines
+	for i, state := range ctx.LogStates {
+		if state.source == closed && !state.Ended {
+			endTab(ctx, i)
+		}
+	}
+}
+
+// updateTab changes the config of a tab. If the command or file changed, the log is restarted,
+// keeping the entries read so far.
+func updateTab(ctx *Context, index int, logConfig LogConfig) {
+	state := ctx.LogStates[index]
+	old := ctx.Config.Logs[index]
+	ctx.Config.Logs[index] = logConfig
+	ctx.Tabs.TabNames[index] = tabTitle(ctx, index)
+
+	if old.Filter != logConfig.Filter {
+		state.filterRe = compileFilter(logConfig.Filter)
+		setFilter(ctx, index, state.Filter)
+	}
+	if old.Command != logConfig.Command || old.File != logConfig.File {
+		// the new log may have another format, its pattern is detected again
+		flushSample(ctx, index)
+		state.detected, state.detectedPattern = false, ""
+		if index == ctx.Tabs.ActiveTabIndex {
+			updateInfoStatus(ctx)
+		}
+		stopLog(state)
+		go listenLog(ctx, state)
+	}
+}
+
+func compileFilter(filter string) *regexp.Regexp {
+	if filter == "" {
+		return nil
+	}
+	filterRe, _ := regexp.Compile(filter)
+	return filterRe
+}
+
+func stopLog(state *LogState) {
+	if state.cmd != nil && state.cmd.Process != nil {
+		state.cmd.Process.Kill()
+	}
+	state.cmd = nil
+	state.generation++
+}
+
+// tabIndex finds the tab of the log state, returns -1 if it was closed.
+func tabIndex(ctx *Context, state *LogState) int {
+	for i, other := range ctx.LogStates {
+		if other == state {
+			return i
+		}
+	}
+	return -1
+}
+
+// switchTab makes the tab active and redraws the screen.
+func switchTab(ctx *Context, index int) {
+	ctx.Tabs.ActiveTabIndex = index
+	ctx.VisualAnchor = nil
+	ctx.ActiveRow = -1
+	ctx.LogTables[index].ActiveRowIndex = ctx.ActiveRow
+	redrawTabs(ctx)
+}
+
+// redrawTabs redraws the tab bar, the active tab, which may have no size yet, and its status.
+func redrawTabs(ctx *Context) {
+	updateSelectedRowStyle(ctx)
+	setViewText(ctx)
+	updateGridLayout(ctx)
+	render(ctx.Grid, ctx.Tabs)
+	updateInfoStatus(ctx)
+}
+
+// promptOpenTab asks for a command or a file path and opens it in a new tab.
+func promptOpenTab(ctx *Context) {
+	showPrompt(ctx, "Open command or file:", "", func(value string) {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			return
+		}
+		logConfig := LogConfig{Title: value, Command: value}
+		if path := expandHome(value); isFile(path) {
+			logConfig = LogConfig{Title: filepath.Base(path), File: path}
+		}
+		switchTab(ctx, openTab(ctx, logConfig, false))
+	})
+}
+
+func isFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular()
+}
+
+// closeActiveTab closes the active tab, unless it is the last one.
+func closeActiveTab(ctx *Context) {
+	if len(ctx.LogStates) == 1 {
+		flashInfo(ctx, "Can't close the last tab", alertInfoStyle)
+		return
+	}
+	closeTab(ctx, ctx.Tabs.ActiveTabIndex)
+	redrawTabs(ctx)
+}
+
+// promptTab shows a prompt for the active tab. The answer is handled with the index the tab has
+// then, or dropped if a reload closed the tab while the prompt was shown.
+func promptTab(ctx *Context, label string, value string, onSubmit func(index int, value string)) {
+	state := ctx.LogStates[ctx.Tabs.ActiveTabIndex]
+	showPrompt(ctx, label, value, func(value string) {
+		if index := tabIndex(ctx, state); index != -1 {
+			onSubmit(index, value)
+		}
+	})
+}
+
+// promptDuplicateTab shows the log of the active tab in a new tab, with a filter regexp.
+func promptDuplicateTab(ctx *Context) {
+	promptTab(ctx, "Duplicate with filter:", ctx.Config.Logs[ctx.Tabs.ActiveTabIndex].Filter, func(index int, filter string) {
+		if _, err := regexp.Compile(filter); err != nil {
+			flashInfo(ctx, fmt.Sprintf("Invalid filter: %v", err), alertInfoStyle)
+			return
+		}
+		switchTab(ctx, duplicateTab(ctx, index, filter))
+	})
+}
+
+// duplicateTab adds a tab with a copy of the entries of the tab and another filter, returns its index.
+// It gets the lines of the same log listener, no new process is started.
+func duplicateTab(ctx *Context, index int, filter string) int {
+	origin := ctx.LogStates[index]
+	logConfig := ctx.Config.Logs[index]
+
+	logConfig.Filter = filter
+	if filter != "" {
+		logConfig.Title = fmt.Sprintf("%s /%s/", logConfig.Title, filter)
+	}
+	state := addTab(ctx, logConfig, false)
+	state.source = origin
+	if origin.source != nil {
+		state.source = origin.source
+	}
+	state.Ended = origin.Ended
+	state.entryLines, state.entryClosed, state.entryLast = origin.entryLines, origin.entryClosed, origin.entryLast
+	state.entryFinished = origin.entryFinished
+	state.sample = append([]sampledLine{}, origin.sample...)
+	state.detected, state.detectedPattern = origin.detected, origin.detectedPattern
+
+	// entries are copied, so marks and merged duplicates are kept apart
+	for i := len(origin.Entries) - 1; i >= 0; i-- {
+		entry := *origin.Entries[i]
+		entry.Marked = false
+		entry.Occurrences = append([]LogOccurrence(nil), entry.Occurrences...)
+		entry.Cluster = state.Clusters.Add(entry.Text, entry.FirstSeen)
+		state.Rates.Add(entry.FirstSeen, detectLevel(entry.Text) >= LevelError)
+		state.Entries = append([]*LogEntry{&entry}, state.Entries...)
+	}
+	index = len(ctx.LogStates) - 1
+	setFilter(ctx, index, nil)
+	return index
+}
+
+func promptRenameTab(ctx *Context) {
+	promptTab(ctx, "Rename tab:", ctx.Config.Logs[ctx.Tabs.ActiveTabIndex].Title, func(index int, title string) {
+		if title == "" {
+			return
+		}
+		logConfig := ctx.Config.Logs[index]
+		logConfig.Title = title
+		updateTab(ctx, index, logConfig)
+		render(ctx.Tabs)
+	})
+}
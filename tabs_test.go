@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// visibleTexts returns the first lines of the entries shown in the tab, newest first.
+func visibleTexts(state *LogState) []string {
+	texts := []string{}
+	for _, entry := range state.Visible {
+		texts = append(texts, firstLine(entry.Text))
+	}
+	return texts
+}
+
+func TestDuplicateTab(t *testing.T) {
+	h := newHarness(t, LogConfig{Title: "api", Command: "app", EntryPattern: `^\d{4}-`})
+	h.feed(0, harnessLines[:5]...)
+
+	index := duplicateTab(h.ctx, 0, "handler")
+	origin, duplicate := h.ctx.LogStates[0], h.ctx.LogStates[index]
+	if duplicate.cmd != nil || h.ctx.Config.Logs[index].Title != "api /handler/" {
+		t.Fatalf("duplicate %q started a process or has the wrong title", h.ctx.Config.Logs[index].Title)
+	}
+	want := []string{"2024-05-01 12:00:02 ERROR request failed"}
+	if got := visibleTexts(duplicate); !slices.Equal(got, want) {
+		t.Errorf("duplicate shows %q, want %q", got, want)
+	}
+
+	// lines of the original listener go to both tabs
+	h.feed(0, "  at server.go:7", "2024-05-01 12:00:03 INFO handler done")
+	want = []string{"2024-05-01 12:00:03 INFO handler done", "2024-05-01 12:00:02 ERROR request failed"}
+	if got := visibleTexts(duplicate); !slices.Equal(got, want) {
+		t.Errorf("duplicate shows %q, want %q", got, want)
+	}
+	if got := duplicate.Visible[1].Text; !strings.HasSuffix(got, "at server.go:7") {
+		t.Errorf("the continuation line wasn't added to the duplicate: %q", got)
+	}
+	if len(origin.Visible) != 4 {
+		t.Errorf("original shows %d entries, want 4", len(origin.Visible))
+	}
+
+	closeTab(h.ctx, 0)
+	if !duplicate.Ended {
+		t.Error("the duplicate isn't ended when its original tab is closed")
+	}
+}
+
+func TestFilterMatchesContinuationLines(t *testing.T) {
+	h := newHarness(t, LogConfig{Title: "api", EntryPattern: `^\d{4}-`, Filter: "handler"})
+	h.feed(0, harnessLines...)
+	state := h.ctx.LogStates[0]
+	fed := visibleTexts(state)
+
+	setFilter(h.ctx, 0, nil)
+	if filtered := visibleTexts(state); !slices.Equal(fed, filtered) {
+		t.Errorf("while reading the filter shows %q, applied later %q", fed, filtered)
+	}
+	if want := []string{"2024-05-01 12:00:02 ERROR request failed"}; !slices.Equal(fed, want) {
+		t.Errorf("filter shows %q, want %q", fed, want)
+	}
+}
+
+func TestSaveTabsKeepsJSON(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(file, []byte(`{"rate_window": "1m", "logs": [{"title": "api", "command": "app"}], "clipboard": {"mode": "osc52"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	h := newHarness(t, LogConfig{Title: "api", Command: "app"}, LogConfig{Title: "a<b", Command: "tail -f b.log", Dedupe: true})
+	for _, state := range h.ctx.LogStates[:1] {
+		state.configTitle = "api"
+	}
+	if err := saveTabs(h.ctx, file); err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := os.ReadFile(file)
+	var saved struct {
+		RateWindow string      `json:"rate_window"`
+		Logs       []LogConfig `json:"logs"`
+	}
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatalf("%v in %s", err, data)
+	}
+	if saved.RateWindow != "1m" || len(saved.Logs) != 2 || saved.Logs[1].Title != "a<b" {
+		t.Errorf("saved %s", data)
+	}
+	if !strings.HasPrefix(string(data), "{\n  \"rate_window\": \"1m\",\n  \"logs\": [") {
+		t.Errorf("the order of the keys changed: %s", data)
+	}
+	if !strings.Contains(string(data), `"dedupe": true`) {
+		t.Errorf("booleans aren't written as JSON: %s", data)
+	}
+}
@@ -0,0 +1,26 @@
+package widgets
+
+import (
+	"image"
+	"strings"
+
+	termui "github.com/gizak/termui/v3"
+)
+
+// BufferText returns the runes of the buffer as lines, without trailing spaces.
+// It is used to compare rendered widgets with text snapshots.
+func BufferText(buf *termui.Buffer) string {
+	lines := []string{}
+	for y := buf.Min.Y; y < buf.Max.Y; y++ {
+		line := []rune{}
+		for x := buf.Min.X; x < buf.Max.X; x++ {
+			r := buf.GetCell(image.Pt(x, y)).Rune
+			if r == 0 {
+				r = ' '
+			}
+			line = append(line, r)
+		}
+		lines = append(lines, strings.TrimRight(string(line), " "))
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
@@ -19,6 +19,8 @@ type List struct {
 	TextStyle        termui.Style
 	SelectedRow      int
 	topRow           int
+	// ColumnOffset is the first column of the rows shown when WrapText is off
+	ColumnOffset     int
 	SelectedRowStyle termui.Style
 }
 
@@ -67,6 +69,8 @@ func (self *List) Draw(buf *termui.Buffer) {
 		cells := ParseRawStyles(self.Rows[row], self.TextStyle)
 		if self.WrapText {
 			cells, _ = WrapCells(cells, uint(self.Inner.Dx()))
+		} else if self.ColumnOffset > 0 {
+			cells = skipColumns(cells, self.ColumnOffset)
 		}
 		for j := 0; j < len(cells) && point.Y < self.Inner.Max.Y; j++ {
 			style := cells[j].Style
@@ -112,6 +116,28 @@ func (self *List) ScrollAmount(amount int) {
 	}
 }
 
+// ScrollColumns scrolls the rows horizontally by amount given, up to the end of the widest row.
+// If amount is < 0, then scroll left.
+func (self *List) ScrollColumns(amount int) {
+	width := 0
+	for _, row := range self.Rows {
+		width = max(width, rw.StringWidth(StripAsciiCodes(row)))
+	}
+	self.ColumnOffset = max(0, min(self.ColumnOffset+amount, width-self.Inner.Dx()))
+}
+
+// skipColumns drops the cells of the first columns, a wide rune cut by the offset is dropped too.
+func skipColumns(cells []termui.Cell, offset int) []termui.Cell {
+	column := 0
+	for i, cell := range cells {
+		if column >= offset {
+			return cells[i:]
+		}
+		column += rw.RuneWidth(cell.Rune)
+	}
+	return nil
+}
+
 func (self *List) ScrollUp() {
 	self.ScrollAmount(-1)
 }
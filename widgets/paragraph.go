@@ -6,8 +6,10 @@ package widgets
 
 import (
 	"image"
+	"unicode"
 
 	termui "github.com/gizak/termui/v3"
+	rw "github.com/mattn/go-runewidth"
 	"github.com/mitchellh/go-wordwrap"
 )
 
@@ -46,42 +48,122 @@ func (self *RawParagraph) Draw(buf *termui.Buffer) {
 	}
 }
 
+// Paragraph is termui's paragraph, with its markup parsed by ParseStyles.
+type Paragraph struct {
+	termui.Block
+	Text      string
+	TextStyle termui.Style
+	WrapText  bool
+}
+
+func NewParagraph() *Paragraph {
+	return &Paragraph{
+		Block:     *termui.NewBlock(),
+		TextStyle: termui.Theme.Paragraph.Text,
+		WrapText:  true,
+	}
+}
+
+func (self *Paragraph) Draw(buf *termui.Buffer) {
+	self.Block.Draw(buf)
+
+	cells := ParseStyles(self.Text, self.TextStyle)
+	if self.WrapText {
+		cells, _ = WrapCells(cells, uint(self.Inner.Dx()))
+	}
+
+	rows := termui.SplitCells(cells, '\n')
+
+	for y, row := range rows {
+		if y+self.Inner.Min.Y >= self.Inner.Max.Y {
+			break
+		}
+		row = termui.TrimCells(row, self.Inner.Dx())
+		for _, cx := range termui.BuildCellWithXArray(row) {
+			x, cell := cx.X, cx.Cell
+			buf.SetCell(cell, image.Pt(x, y).Add(self.Inner.Min))
+		}
+	}
+}
+
 func WrapCells(cells []termui.Cell, width uint) ([]termui.Cell, int) {
 	str := termui.CellsToString(cells)
 	wrapped := wordwrap.WrapString(str, width)
-	
-	return ForceWrap(wrapped, width, cells)
+
+	// wordwrap drops spaces at the line breaks it adds, align the styles to the wrapped text
+	aligned := make([]termui.Cell, 0, len(cells))
+	j := 0
+	for _, char := range wrapped {
+		if char == '\n' && (j >= len(cells) || cells[j].Rune != '\n') {
+			aligned = append(aligned, termui.Cell{Rune: char, Style: termui.StyleClear})
+			continue
+		}
+		for j < len(cells) && cells[j].Rune != char && unicode.IsSpace(cells[j].Rune) {
+			j++
+		}
+		style := termui.StyleClear
+		if j < len(cells) {
+			style = cells[j].Style
+			j++
+		}
+		aligned = append(aligned, termui.Cell{Rune: char, Style: style})
+	}
+
+	return ForceWrap(wrapped, width, aligned)
 }
 
+// ForceWrap breaks lines of str wider than width columns, marking the breaks with ⏎. Styles are taken
+// from the cells by rune index.
 func ForceWrap(str string, width uint, cells []termui.Cell) ([]termui.Cell, int) {
 	wrappedCells := []termui.Cell{}
 	lineCount := 1
+	runes := []rune(str)
+
+	style := func(i int) termui.Style {
+		if i < len(cells) {
+			return cells[i].Style
+		}
+		return termui.StyleClear
+	}
+	// lineFits reports whether the rest of the line, starting at i, is at most n columns wide
+	lineFits := func(i int, n uint) bool {
+		var w uint = 0
+		for j := i; j < len(runes) && runes[j] != '\n'; j++ {
+			w += uint(rw.RuneWidth(runes[j]))
+			if w > n {
+				return false
+			}
+		}
+		return true
+	}
 
 	var col uint = 0
-	for i, char := range str {
+	for i, char := range runes {
 		if char == '\n' {
 			col = 0
 			wrappedCells = append(wrappedCells, termui.Cell{Rune: '\n', Style: termui.StyleClear})
 			lineCount++
-		} else if col == width - 3 && len(str) > i + 3 && str[i+1] != '\n' && str[i+2] != '\n' && str[i+3] != '\n' {
+			continue
+		}
+		charWidth := uint(rw.RuneWidth(char))
+		if width > 3 && col + charWidth + 2 > width && !lineFits(i, width - col) {
+			// the marker takes 2 columns
 			col = 0
 			wrappedCells = append(wrappedCells,
-				termui.Cell{Rune: char, Style: cells[i].Style},
 				termui.Cell{Rune: ' ', Style: termui.StyleClear},
 				termui.Cell{Rune: '⏎', Style: termui.NewStyle(termui.ColorYellow)},
 				termui.Cell{Rune: '\n', Style: termui.StyleClear},
 			)
 			lineCount++
-		} else {
-			col++
-			style := termui.StyleClear
-			if len(cells) > i {
-				// TODO: shouldn't get here
-				style = cells[i].Style
-			}
-			wrappedCells = append(wrappedCells, termui.Cell{Rune: char, Style: style})
+		} else if width > 0 && width <= 3 && col > 0 && col + charWidth > width {
+			// too narrow for the marker
+			col = 0
+			wrappedCells = append(wrappedCells, termui.Cell{Rune: '\n', Style: termui.StyleClear})
+			lineCount++
 		}
+		col += charWidth
+		wrappedCells = append(wrappedCells, termui.Cell{Rune: char, Style: style(i)})
 	}
 
 	return wrappedCells, lineCount
-}
\ No newline at end of file
+}
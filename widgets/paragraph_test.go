@@ -0,0 +1,103 @@
+package widgets
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"unicode"
+
+	termui "github.com/gizak/termui/v3"
+	rw "github.com/mattn/go-runewidth"
+)
+
+var wrapMarkerStyle = termui.NewStyle(termui.ColorYellow)
+
+// checkWrapped checks that no line of the wrapped cells is wider than the width and returns
+// the text without the wrap markers.
+func checkWrapped(t *testing.T, cells []termui.Cell, lineCount int, width uint) string {
+	t.Helper()
+	lines := [][]termui.Cell{{}}
+	for _, cell := range cells {
+		if cell.Rune == '\n' {
+			lines = append(lines, []termui.Cell{})
+		} else {
+			lines[len(lines)-1] = append(lines[len(lines)-1], cell)
+		}
+	}
+	if len(lines) != lineCount {
+		t.Errorf("line count is %d, got %d lines", lineCount, len(lines))
+	}
+	for _, line := range lines {
+		// a rune wider than the width gets a line of its own
+		if uint(rw.StringWidth(cellsText(line))) > width && len(line) > 1 {
+			t.Errorf("line %q is wider than %d", cellsText(line), width)
+		}
+	}
+
+	var unwrapped []termui.Cell
+	for i := 0; i < len(cells); i++ {
+		if i+2 < len(cells) && cells[i].Rune == ' ' && cells[i].Style == termui.StyleClear &&
+			cells[i+1].Rune == '⏎' && cells[i+1].Style == wrapMarkerStyle && cells[i+2].Rune == '\n' {
+			i += 2
+			continue
+		}
+		unwrapped = append(unwrapped, cells[i])
+	}
+	return cellsText(unwrapped)
+}
+
+func FuzzForceWrap(f *testing.F) {
+	for _, seed := range ansiSeeds {
+		f.Add(seed, uint(10))
+	}
+	f.Add("a line long enough to be wrapped twice", uint(12))
+	f.Add("abcd\nefgh", uint(4))
+	f.Add("abcdefgh", uint(2))
+	f.Add("中文中文中文", uint(4))
+	f.Add("中文中文中文中文中文", uint(6))
+	f.Add("ab中文c😀d😀😀ef", uint(5))
+	f.Add("中😀", uint(1))
+	f.Fuzz(func(t *testing.T, s string, width uint) {
+		width = width%200 + 1
+		text := StripAsciiCodes(s)
+		cells, lineCount := ForceWrap(text, width, ParseRawStyles(s, termui.NewStyle(termui.ColorWhite)))
+		if width > 3 {
+			// inserted line breaks can only be told apart by the marker
+			if got := checkWrapped(t, cells, lineCount, width); got != string([]rune(text)) {
+				t.Errorf("ForceWrap(%q, %d) changed the text to %q", text, width, got)
+			}
+		} else {
+			checkWrapped(t, cells, lineCount, width)
+			if got := strings.ReplaceAll(cellsText(cells), "\n", ""); got != strings.ReplaceAll(string([]rune(text)), "\n", "") {
+				t.Errorf("ForceWrap(%q, %d) changed the text to %q", text, width, got)
+			}
+		}
+	})
+}
+
+func FuzzWrapCells(f *testing.F) {
+	for _, seed := range ansiSeeds {
+		f.Add(seed, uint(10))
+	}
+	f.Add("words and averyveryverylongword wrapped", uint(8))
+	f.Fuzz(func(t *testing.T, s string, width uint) {
+		width = width%200 + 1
+		input := ParseRawStyles(s, termui.NewStyle(termui.ColorWhite))
+		cells, lineCount := WrapCells(input, width)
+		checkWrapped(t, cells, lineCount, width)
+
+		// only spaces and line breaks change, the other runes keep their style
+		visible := func(cells []termui.Cell) []termui.Cell {
+			result := []termui.Cell{}
+			for _, cell := range cells {
+				if !unicode.IsSpace(cell.Rune) && !(cell.Rune == '⏎' && cell.Style == wrapMarkerStyle) {
+					result = append(result, cell)
+				}
+			}
+			return result
+		}
+		if got, want := visible(cells), visible(input); !reflect.DeepEqual(got, want) {
+			t.Errorf("WrapCells(%q, %d) changed the text or styles to %q", s, width, cellsText(got))
+		}
+	})
+}
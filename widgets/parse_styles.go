@@ -1,11 +1,14 @@
 package widgets
 
 import (
+	"strconv"
 	"strings"
 
 	termui "github.com/gizak/termui/v3"
 )
 
+// ParseRawStyles converts text with SGR escape sequences to styled cells. Other control sequences
+// are dropped, so the runes of the cells are the same as StripAsciiCodes of the text.
 func ParseRawStyles(s string, defaultStyle termui.Style) []termui.Cell {
 	cells := []termui.Cell{}
 	runes := []rune(s)
@@ -13,50 +16,14 @@ func ParseRawStyles(s string, defaultStyle termui.Style) []termui.Cell {
 	style := defaultStyle
 	for i := 0; i < len(runes); i++ {
 		_rune := runes[i]
-		if _rune == 27 && runes[i+1] == '[' {
-			style = defaultStyle
-			i += 2
-			for {
-				if runes[i+1] == ';' || runes[i+1] == 'm' {
-					switch (runes[i] - '0') {
-					case 0:
-						style.Modifier = termui.ModifierClear
-					case 1:
-						style.Modifier = termui.ModifierBold
-					case 4:
-						style.Modifier = termui.ModifierUnderline
-					}
-					if runes[i+1] == 'm' {
-						i += 2
-						break
-					}
-					i += 2
-				} else if runes[i+2] == ';' || runes[i+2] == 'm' {
-					color := runes[i+1] - '0'
-					switch  (runes[i] - '0') {
-					case 3:
-						if color == 9 {
-							style.Fg = defaultStyle.Fg
-						} else {
-							style.Fg = termui.Color(color)
-						}
-					case 4:
-						if color == 9 {
-							style.Bg = defaultStyle.Bg
-						} else {
-							style.Bg = termui.Color(color)
-						}
-					}
-					if runes[i+2] == 'm' {
-						i += 3
-						break
-					}
-					i += 3
-				} else {
-					break
+		if _rune == 27 && i+1 < len(runes) && runes[i+1] == '[' {
+			if end := csiEnd(runes, i+2); end > -1 {
+				if runes[end] == 'm' {
+					style = parseSGR(string(runes[i+2:end]), style, defaultStyle)
 				}
+				i = end
+				continue
 			}
-			_rune = runes[i]
 		}
 		cells = append(cells, termui.Cell{Rune: _rune, Style: style})
 	}
@@ -64,16 +31,113 @@ func ParseRawStyles(s string, defaultStyle termui.Style) []termui.Cell {
 	return cells
 }
 
+// ParseStyles parses the [text](fg:red,mod:bold) markup of termui. Unlike termui.ParseStyles, it keeps
+// the last rune of a text ending after an unclosed [, like a regexp.
+func ParseStyles(s string, defaultStyle termui.Style) []termui.Cell {
+	cells := []termui.Cell{}
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] == '[' {
+			if end := markupEnd(runes, i); end > -1 {
+				// termui parses a single well-formed span right
+				cells = append(cells, termui.ParseStyles(string(runes[i:end+1]), defaultStyle)...)
+				i = end
+				continue
+			}
+		}
+		cells = append(cells, termui.Cell{Rune: runes[i], Style: defaultStyle})
+	}
+	return cells
+}
+
+// markupEnd returns the index of the ) ending the styled text starting at the [ at start, or -1.
+func markupEnd(runes []rune, start int) int {
+	depth := 0
+	for i := start; i < len(runes); i++ {
+		switch runes[i] {
+		case '[':
+			depth++
+		case ']':
+			depth--
+		}
+		if depth > 0 {
+			continue
+		}
+		if i+1 >= len(runes) || runes[i+1] != '(' {
+			return -1
+		}
+		for j := i + 2; j < len(runes); j++ {
+			if runes[j] == ')' {
+				return j
+			}
+		}
+		return -1
+	}
+	return -1
+}
+
+// parseSGR returns the style set by the parameters of a "select graphic rendition" sequence
+// on top of the current style. Reset (0 or no parameter) goes back to the default style.
+func parseSGR(params string, style, defaultStyle termui.Style) termui.Style {
+	codes := strings.Split(params, ";")
+	for i := 0; i < len(codes); i++ {
+		if codes[i] == "" {
+			codes[i] = "0"
+		}
+		code, err := strconv.Atoi(codes[i])
+		if err != nil {
+			continue
+		}
+		switch {
+		case code == 0:
+			style = defaultStyle
+		case code == 1:
+			style.Modifier |= termui.ModifierBold
+		case code == 4:
+			style.Modifier |= termui.ModifierUnderline
+		case code == 39:
+			style.Fg = defaultStyle.Fg
+		case code == 49:
+			style.Bg = defaultStyle.Bg
+		case code >= 30 && code <= 37:
+			style.Fg = termui.Color(code - 30)
+		case code >= 40 && code <= 47:
+			style.Bg = termui.Color(code - 40)
+		case code == 38 || code == 48:
+			// 256 colors as 38;5;n, true colors (38;2;r;g;b) are skipped
+			var color termui.Color = -1
+			if i+2 < len(codes) && codes[i+1] == "5" {
+				if n, err := strconv.Atoi(codes[i+2]); err == nil && n >= 0 && n <= 255 {
+					color = termui.Color(n)
+				}
+				i += 2
+			} else if i+1 < len(codes) && codes[i+1] == "2" {
+				i += 4
+			}
+			if color == -1 {
+				continue
+			}
+			if code == 38 {
+				style.Fg = color
+			} else {
+				style.Bg = color
+			}
+		}
+	}
+	return style
+}
+
+// StripAsciiCodes removes ANSI escape sequences (ESC [ ... final byte) from the string.
 func StripAsciiCodes(str string) string {
 	runes := []rune(str)
 	stripped := []rune{}
 
 	for i := 0; i < len(runes); i++ {
 		_rune := runes[i]
-		if _rune == 27 && runes[i+1] == '[' {
-			idx := strings.IndexRune(str[i:], 'm')
-			if idx > -1 {
-				i += idx
+		if _rune == 27 && i+1 < len(runes) && runes[i+1] == '[' {
+			end := csiEnd(runes, i+2)
+			if end > -1 {
+				i = end
 				continue
 			}
 		}
@@ -81,4 +145,52 @@ func StripAsciiCodes(str string) string {
 	}
 
 	return string(stripped)
-}
\ No newline at end of file
+}
+
+// StripAsciiCodesIndex is StripAsciiCodes, also returning where each byte of the stripped string
+// starts in str, including the escape sequences before it, and the end of str.
+func StripAsciiCodesIndex(str string) (string, []int) {
+	runes := []rune(str)
+	// starts are the offsets of the runes in str
+	starts := make([]int, 0, len(runes)+1)
+	for offset := range str {
+		starts = append(starts, offset)
+	}
+	starts = append(starts, len(str))
+
+	var stripped strings.Builder
+	offsets := []int{}
+	// after is the end of the last rune kept
+	after := 0
+	for i := 0; i < len(runes); i++ {
+		_rune := runes[i]
+		if _rune == 27 && i+1 < len(runes) && runes[i+1] == '[' {
+			end := csiEnd(runes, i+2)
+			if end > -1 {
+				i = end
+				continue
+			}
+		}
+		n, _ := stripped.WriteRune(_rune)
+		offsets = append(offsets, after)
+		for j := 1; j < n; j++ {
+			offsets = append(offsets, min(starts[i]+j, starts[i+1]))
+		}
+		after = starts[i+1]
+	}
+	return stripped.String(), append(offsets, len(str))
+}
+
+// csiEnd returns the index of the final byte of a control sequence which parameters start at from,
+// or -1 if the sequence isn't terminated.
+func csiEnd(runes []rune, from int) int {
+	for i := from; i < len(runes); i++ {
+		if runes[i] >= '@' && runes[i] <= '~' {
+			return i
+		}
+		if runes[i] < ' ' || runes[i] > '?' {
+			return -1
+		}
+	}
+	return -1
+}
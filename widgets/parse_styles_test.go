@@ -0,0 +1,142 @@
+package widgets
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	termui "github.com/gizak/termui/v3"
+)
+
+var ansiSeeds = []string{
+	"",
+	"plain text",
+	"\x1b[31merror\x1b[0m done",
+	"\x1b[1;4;32mbold green\x1b[39m",
+	"\x1b[38;5;208morange\x1b[48;5;17m on blue",
+	"\x1b[38;2;255;0;0mtrue color",
+	"\x1b[2K\x1b[1Gprogress 50%",
+	"\x1b[m",
+	"\x1b[",
+	"\x1b[3",
+	"\x1b[31",
+	"text\x1b",
+	"\x1b\x1b[1m[2m",
+	"\x1b[31\nm",
+	"é\x1b[1m中文\x1b[0m",
+}
+
+func cellsText(cells []termui.Cell) string {
+	var builder strings.Builder
+	for _, cell := range cells {
+		builder.WriteRune(cell.Rune)
+	}
+	return builder.String()
+}
+
+func FuzzParseRawStyles(f *testing.F) {
+	for _, seed := range ansiSeeds {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		cells := ParseRawStyles(s, termui.NewStyle(termui.ColorWhite))
+		if got, want := cellsText(cells), StripAsciiCodes(s); got != want {
+			t.Errorf("ParseRawStyles(%q) runes are %q, StripAsciiCodes gives %q", s, got, want)
+		}
+	})
+}
+
+func FuzzStripAsciiCodes(f *testing.F) {
+	for _, seed := range ansiSeeds {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		stripped := StripAsciiCodes(s)
+		if utf8.RuneCountInString(stripped) > utf8.RuneCountInString(s) {
+			t.Errorf("StripAsciiCodes(%q) = %q is longer than the input", s, stripped)
+		}
+		if !strings.Contains(s, "\x1b") && stripped != string([]rune(s)) {
+			t.Errorf("StripAsciiCodes(%q) = %q changed text without escape codes", s, stripped)
+		}
+		if utf8.ValidString(s) && !utf8.ValidString(stripped) {
+			t.Errorf("StripAsciiCodes(%q) = %q is not valid UTF-8", s, stripped)
+		}
+
+		indexed, offsets := StripAsciiCodesIndex(s)
+		if indexed != stripped || len(offsets) != len(stripped)+1 {
+			t.Fatalf("StripAsciiCodesIndex(%q) = %q with %d offsets, StripAsciiCodes gives %q", s, indexed, len(offsets), stripped)
+		}
+		for i := 1; i < len(offsets); i++ {
+			if offsets[i] < offsets[i-1] || offsets[i] > len(s) {
+				t.Fatalf("StripAsciiCodesIndex(%q) offsets %v aren't ordered", s, offsets)
+			}
+		}
+	})
+}
+
+func TestParseRawStyles(t *testing.T) {
+	defaultStyle := termui.NewStyle(termui.ColorWhite, termui.ColorBlack)
+
+	tests := []struct {
+		name  string
+		input string
+		want  termui.Style
+	}{
+		{"plain", "x", defaultStyle},
+		{"fg", "\x1b[31mx", termui.NewStyle(termui.ColorRed, termui.ColorBlack)},
+		{"bg and bold", "\x1b[1;44mx", termui.NewStyle(termui.ColorWhite, termui.ColorBlue, termui.ModifierBold)},
+		{"256 colors", "\x1b[38;5;208;48;5;17mx", termui.NewStyle(termui.Color(208), termui.Color(17))},
+		{"true color skipped", "\x1b[38;2;1;2;3;4mx", termui.NewStyle(termui.ColorWhite, termui.ColorBlack, termui.ModifierUnderline)},
+		{"back to back", "\x1b[1m\x1b[31m\x1b[44mx", termui.NewStyle(termui.ColorRed, termui.ColorBlue, termui.ModifierBold)},
+		{"bold and underline", "\x1b[1m\x1b[4mx", termui.NewStyle(termui.ColorWhite, termui.ColorBlack, termui.ModifierBold|termui.ModifierUnderline)},
+		{"default fg", "\x1b[31m\x1b[39mx", defaultStyle},
+		{"reset", "\x1b[31m\x1b[mx", defaultStyle},
+		{"other sequence", "\x1b[31m\x1b[2Kx", termui.NewStyle(termui.ColorRed, termui.ColorBlack)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cells := ParseRawStyles(test.input, defaultStyle)
+			if len(cells) != 1 || cells[0].Rune != 'x' {
+				t.Fatalf("got cells %q, want x", cellsText(cells))
+			}
+			if cells[0].Style != test.want {
+				t.Errorf("got style %+v, want %+v", cells[0].Style, test.want)
+			}
+		})
+	}
+}
+
+func TestParseStyles(t *testing.T) {
+	defaultStyle := termui.NewStyle(termui.ColorWhite)
+	yellow := termui.NewStyle(termui.ColorYellow)
+
+	tests := []struct {
+		name   string
+		input  string
+		text   string
+		styled string
+	}{
+		{"styled", "Press [l](fg:yellow) to", "Press l to", "l"},
+		{"unclosed at the end", `pattern: '^\[?\d{4}[T ]\d'`, `pattern: '^\[?\d{4}[T ]\d'`, ""},
+		{"bracket at the end", "a [", "a [", ""},
+		{"without style", "[a] (b)", "[a] (b)", ""},
+		{"unclosed before a styled text", "[a [b](fg:yellow)", "[a b", "b"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cells := ParseStyles(test.input, defaultStyle)
+			if got := cellsText(cells); got != test.text {
+				t.Errorf("got text %q, want %q", got, test.text)
+			}
+			styled := []rune{}
+			for _, cell := range cells {
+				if cell.Style == yellow {
+					styled = append(styled, cell.Rune)
+				}
+			}
+			if string(styled) != test.styled {
+				t.Errorf("got styled %q, want %q", string(styled), test.styled)
+			}
+		})
+	}
+}
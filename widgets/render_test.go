@@ -0,0 +1,194 @@
+package widgets
+
+import (
+	"flag"
+	"image"
+	"os"
+	"path/filepath"
+	"testing"
+
+	termui "github.com/gizak/termui/v3"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// assertGolden compares the text with testdata/<name>.golden, or writes it with -update.
+func assertGolden(t *testing.T, name string, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v, run with -update to create it", err)
+	}
+	if got != string(want) {
+		t.Errorf("%s differs from the snapshot:\n--- got\n%s--- want\n%s", name, got, want)
+	}
+}
+
+// renderText draws the widget into a buffer of its size and returns the text.
+func renderText(drawable termui.Drawable) string {
+	buf := termui.NewBuffer(drawable.GetRect())
+	drawable.Draw(buf)
+	return BufferText(buf)
+}
+
+func newTestTable(rows ...string) *RawTable {
+	table := NewRawTable()
+	table.Border = false
+	table.ColumnWidths = []int{18}
+	table.PaddingRight = 1
+	for _, row := range rows {
+		table.Rows = append(table.Rows, []string{row})
+	}
+	table.SetRect(0, 0, 20, 7)
+	return table
+}
+
+func TestRawTableDraw(t *testing.T) {
+	rows := []string{"first", "second", "third", "fourth", "fifth", "a row too long for the column"}
+
+	tests := []struct {
+		name   string
+		active int
+		scroll int
+		marked []int
+	}{
+		{name: "table_inactive", active: -1},
+		{name: "table_active_first", active: 0},
+		{name: "table_active_middle", active: 2},
+		{name: "table_active_scrolled", active: 5},
+		{name: "table_scrolled_back", active: 1, scroll: 4},
+		{name: "table_marked", active: 1, marked: []int{0, 2}},
+		{name: "table_marked_active_last", active: 5, marked: []int{4, 5}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			table := newTestTable(rows...)
+			table.ActiveRowIndex = test.active
+			table.ScrollTop = test.scroll
+			if test.marked != nil {
+				table.RowMarked = func(row int) bool {
+					for _, marked := range test.marked {
+						if marked == row {
+							return true
+						}
+					}
+					return false
+				}
+			}
+			assertGolden(t, test.name, renderText(table))
+		})
+	}
+}
+
+// the marks gutter narrows the first column, so the columns and their separators still fit the table
+func TestRawTableMarkedColumns(t *testing.T) {
+	table := NewRawTable()
+	table.Border = false
+	table.ColumnWidths = []int{9, 9}
+	table.Rows = [][]string{{"12:00:01", "a message"}, {"12:00:02", "another one"}}
+	table.RowMarked = func(row int) bool { return row == 1 }
+	table.SetRect(0, 0, 19, 5)
+	assertGolden(t, "table_marked_columns", renderText(table))
+}
+
+func TestRawTableScrollTop(t *testing.T) {
+	table := newTestTable("1", "2", "3", "4", "5", "6", "7", "8")
+	buf := termui.NewBuffer(table.GetRect())
+
+	// the inner height of 5 lines fits 3 rows with separators
+	for active, scrollTop := range []int{0, 0, 0, 1, 2, 3, 4, 5} {
+		table.ActiveRowIndex = active
+		table.Draw(buf)
+		if table.ScrollTop != scrollTop {
+			t.Errorf("active row %d: ScrollTop = %d, want %d", active, table.ScrollTop, scrollTop)
+		}
+	}
+	// moving up scrolls as soon as the active row leaves the top
+	for _, active := range []int{4, 3, 2, 1, 0} {
+		table.ActiveRowIndex = active
+		table.Draw(buf)
+		if want := min(5, active); table.ScrollTop != want {
+			t.Errorf("active row %d: ScrollTop = %d, want %d", active, table.ScrollTop, want)
+		}
+	}
+	table.ActiveRowIndex = -1
+	table.Draw(buf)
+	if table.ScrollTop != 0 {
+		t.Errorf("no active row: ScrollTop = %d, want 0", table.ScrollTop)
+	}
+}
+
+func TestListDraw(t *testing.T) {
+	rows := []string{
+		"short",
+		"a line long enough to be wrapped in the list",
+		"\x1b[33mstyled\x1b[39m text",
+		"fourth",
+		"fifth",
+		"sixth",
+	}
+
+	tests := []struct {
+		name     string
+		wrap     bool
+		selected int
+		columns  int
+	}{
+		{name: "list_top", selected: 0},
+		{name: "list_scrolled", selected: 5},
+		{name: "list_wrapped", wrap: true, selected: 0},
+		{name: "list_wrapped_scrolled", wrap: true, selected: 5},
+		{name: "list_columns", selected: 5, columns: 7},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			list := NewList()
+			list.Rows = rows
+			list.WrapText = test.wrap
+			list.SelectedRow = test.selected
+			list.SetRect(0, 0, 20, 6)
+			list.ScrollColumns(test.columns)
+			assertGolden(t, test.name, renderText(list))
+		})
+	}
+}
+
+func TestSkipColumns(t *testing.T) {
+	cells := ParseRawStyles("ab世界cd", termui.StyleClear)
+	for offset, want := range []string{"ab世界cd", "b世界cd", "世界cd", "界cd", "界cd", "cd", "cd", "d", ""} {
+		got := ""
+		for _, cell := range skipColumns(cells, offset) {
+			got += string(cell.Rune)
+		}
+		if got != want {
+			t.Errorf("offset %d: got %q, want %q", offset, got, want)
+		}
+	}
+}
+
+func TestDrawScrollbar(t *testing.T) {
+	tests := []struct {
+		name              string
+		first, last, rows int
+	}{
+		{name: "scrollbar_none", first: 0, last: 5, rows: 5},
+		{name: "scrollbar_top", first: 0, last: 5, rows: 20},
+		{name: "scrollbar_middle", first: 7, last: 12, rows: 20},
+		{name: "scrollbar_bottom", first: 15, last: 20, rows: 20},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			box := image.Rect(0, 0, 3, 8)
+			buf := termui.NewBuffer(box)
+			DrawScrollbar(buf, box, 0, test.first, test.last, test.rows)
+			assertGolden(t, test.name, BufferText(buf))
+		})
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2017 Zack Guo <zack.y.guo@gmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT license that can
+// be found in the LICENSE file.
+
+package widgets
+
+import (
+	"image"
+
+	termui "github.com/gizak/termui/v3"
+)
+
+// Sparkline is like: ▅▆▂▂▅▇▂▂▃▆▆▆▅▃. The data points should be non-negative.
+// Unlike termui's sparkline, partial bar characters are used for the top cell,
+// so a sparkline of a single row still shows 8 levels.
+type Sparkline struct {
+	Data      []float64
+	LineColor termui.Color
+	MaxVal    float64
+}
+
+type SparklineGroup struct {
+	termui.Block
+	Sparklines []*Sparkline
+}
+
+func NewSparkline() *Sparkline {
+	return &Sparkline{
+		LineColor: termui.Theme.Sparkline.Line,
+	}
+}
+
+func NewSparklineGroup(sls ...*Sparkline) *SparklineGroup {
+	return &SparklineGroup{
+		Block:      *termui.NewBlock(),
+		Sparklines: sls,
+	}
+}
+
+func (self *SparklineGroup) Draw(buf *termui.Buffer) {
+	self.Block.Draw(buf)
+
+	if len(self.Sparklines) == 0 {
+		return
+	}
+	sparklineHeight := self.Inner.Dy() / len(self.Sparklines)
+
+	for i, sl := range self.Sparklines {
+		barHeight := sparklineHeight
+		if i == len(self.Sparklines)-1 {
+			barHeight = self.Inner.Dy() - (sparklineHeight * i)
+		}
+		bottom := self.Inner.Min.Y + sparklineHeight*i + barHeight - 1
+
+		maxVal := sl.MaxVal
+		if maxVal == 0 {
+			maxVal, _ = termui.GetMaxFloat64FromSlice(sl.Data)
+		}
+
+		// the latest data points are aligned to the right
+		offset := self.Inner.Dx() - len(sl.Data)
+		for j := 0; j < len(sl.Data); j++ {
+			if j+offset < 0 {
+				continue
+			}
+			levels := 0
+			if maxVal > 0 {
+				levels = int(sl.Data[j] / maxVal * float64(barHeight*8))
+			}
+			if levels == 0 && sl.Data[j] > 0 {
+				levels = 1
+			}
+			for k := 0; k < barHeight; k++ {
+				level := termui.MinInt(levels-k*8, 8)
+				if level <= 0 {
+					if k == 0 {
+						level = 1
+					} else {
+						break
+					}
+				}
+				buf.SetCell(
+					termui.NewCell(termui.BARS[level], termui.NewStyle(sl.LineColor)),
+					image.Pt(self.Inner.Min.X+j+offset, bottom-k),
+				)
+			}
+		}
+	}
+}
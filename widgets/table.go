@@ -33,6 +33,10 @@ type RawTable struct {
 	ActiveRowSeparatorStyle  termui.Style
 	ScrollTop int
 
+	// RowMarked enables a marker gutter on the left, reports whether the row is marked.
+	RowMarked func(row int) bool
+	MarkerStyle termui.Style
+
 	// ColumnResizer is called on each Draw. Can be used for custom column sizing.
 	ColumnResizer func()
 }
@@ -48,6 +52,7 @@ func NewRawTable() *RawTable {
 		SeparatorStyle: termui.Theme.Block.Border,
 		ActiveRowSeparatorStyle: termui.Theme.Block.Border,
 		ScrollTop: 0,
+		MarkerStyle: termui.NewStyle(termui.ColorYellow),
 	}
 }
 
@@ -67,6 +72,13 @@ func (self *RawTable) Draw(buf *termui.Buffer) {
 
 	yCoordinate := self.Inner.Min.Y
 
+	minX := self.Inner.Min.X
+	if self.RowMarked != nil {
+		// the gutter takes a column from the first one
+		minX++
+		columnWidths = append([]int{columnWidths[0] - 1}, columnWidths[1:]...)
+	}
+
 	maxIndex := self.Inner.Dy() - 1
 	if self.RowSeparator {
 		maxIndex = maxIndex / 2
@@ -89,11 +101,12 @@ func (self *RawTable) Draw(buf *termui.Buffer) {
 	}
 
 	var i int
+	separatorDrawn := false
 
 	// draw rows
 	for i = self.ScrollTop; i < len(self.Rows) && yCoordinate < self.Inner.Max.Y; i++ {
 		row := self.Rows[i]
-		colXCoordinate := self.Inner.Min.X
+		colXCoordinate := minX
 
 		rowStyle := self.TextStyle
 		if i == self.ActiveRowIndex {
@@ -105,6 +118,13 @@ func (self *RawTable) Draw(buf *termui.Buffer) {
 			buf.Fill(blankCell, image.Rect(self.Inner.Min.X, yCoordinate, self.Inner.Max.X, yCoordinate+1))
 		}
 
+		// draw marker gutter
+		if self.RowMarked != nil && self.RowMarked(i) {
+			markerStyle := self.MarkerStyle
+			markerStyle.Bg = rowStyle.Bg
+			buf.SetCell(termui.NewCell('▌', markerStyle), image.Pt(self.Inner.Min.X, yCoordinate))
+		}
+
 		// draw row cells
 		for j := 0; j < len(row); j++ {
 			col := ParseRawStyles(row[j], rowStyle)
@@ -140,7 +160,7 @@ func (self *RawTable) Draw(buf *termui.Buffer) {
 		// draw vertical separators
 		separatorStyle := self.SeparatorStyle
 
-		separatorXCoordinate := self.Inner.Min.X
+		separatorXCoordinate := minX
 		verticalCell := termui.NewCell(termui.VERTICAL_LINE, separatorStyle)
 		for k, width := range columnWidths {
 			if self.FillRow && k < len(columnWidths)-1 {
@@ -150,6 +170,9 @@ func (self *RawTable) Draw(buf *termui.Buffer) {
 			}
 
 			separatorXCoordinate += width
+			if separatorXCoordinate >= self.Max.X {
+				break
+			}
 			buf.SetCell(verticalCell, image.Pt(separatorXCoordinate, yCoordinate))
 			separatorXCoordinate++
 		}
@@ -167,13 +190,15 @@ func (self *RawTable) Draw(buf *termui.Buffer) {
 			separatorStyle = self.ActiveRowSeparatorStyle
 		}
 		horizontalCell := termui.NewCell(separatorSymbol, separatorStyle)
-		if self.RowSeparator && yCoordinate < self.Inner.Max.Y && i != len(self.Rows)-1 {
+		separatorDrawn = self.RowSeparator && yCoordinate < self.Inner.Max.Y && i != len(self.Rows)-1
+		if separatorDrawn {
 			buf.Fill(horizontalCell, image.Rect(self.Inner.Min.X, yCoordinate, self.Inner.Max.X, yCoordinate+1))
 			yCoordinate++
 		}
 	}
 
-	if self.RowSeparator && self.ActiveRowIndex == i {
+	// the last drawn row is active, close it below like the separator above the first row
+	if self.RowSeparator && self.ActiveRowIndex == i-1 && !separatorDrawn {
 		separatorSymbol := '▀'
 		separatorStyle := self.ActiveRowSeparatorStyle
 		cell := termui.NewCell(separatorSymbol, separatorStyle)
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+
+	ui "github.com/gizak/termui/v3"
+)
+
+// columnScrollStep is how many columns Left and Right scroll the log view when lines aren't wrapped.
+const columnScrollStep = 8
+
+// scrollsColumns reports whether Left and Right scroll the log view instead of switching tabs.
+func scrollsColumns(ctx *Context) bool {
+	return ctx.ActivePane == ActiveRight && !ctx.LogView.WrapText && !ctx.OutputShown && !ctx.PatternsShown
+}
+
+func scrollColumns(ctx *Context, amount int) {
+	ctx.LogView.ScrollColumns(amount)
+	updateLogViewTitle(ctx)
+	render(ctx.LogView)
+}
+
+func toggleWrap(ctx *Context) {
+	ctx.LogView.WrapText = !ctx.LogView.WrapText
+	ctx.LogView.ColumnOffset = 0
+	updateLogViewTitle(ctx)
+	render(rightPane(ctx))
+	if ctx.LogView.WrapText {
+		flashInfo(ctx, "Lines wrapped", ui.Theme.Block.Border)
+	} else {
+		flashInfo(ctx, "Lines not wrapped, Left/Right scroll the log view when it's active, Tab back to the list to switch tabs", ui.Theme.Block.Border)
+	}
+}
+
+// updateLogViewTitle shows the first column of the log view in its title when lines aren't wrapped.
+// Without the log list, the log view has no border and no title, the column is shown in the Info bar.
+func updateLogViewTitle(ctx *Context) {
+	switch {
+	case ctx.LeftHidden:
+		ctx.LogView.Title = ""
+	case ctx.LogView.WrapText:
+		ctx.LogView.Title = " Log View "
+	default:
+		ctx.LogView.Title = fmt.Sprintf(" Log View · no wrap, col %d ", ctx.LogView.ColumnOffset+1)
+	}
+	updateInfoStatus(ctx)
+}
+
+// columnStatus is the first column of the log view for the Info bar, when the log view has no title to show it.
+func columnStatus(ctx *Context) string {
+	if ctx.LogView.WrapText || !ctx.LeftHidden {
+		return ""
+	}
+	return fmt.Sprintf("no wrap, col %d", ctx.LogView.ColumnOffset+1)
+}